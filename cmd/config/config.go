@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	output "txtoolbox/cmd/output"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/spf13/cobra"
@@ -38,9 +39,16 @@ var ConfigCmd = &cobra.Command{
 	Short: "Set up a configuration file for use",
 	Long:  figure.NewFigure("config", "", true).String(),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("config/config called")
+		fmt.Fprintln(output.Text, "config/config called")
+		if output.Structured() {
+			settings := make(map[string]any)
+			for k, v := range GetConfig() {
+				settings[k] = MaskSecret(k, v)
+			}
+			return output.Print(settings)
+		}
 		for k, v := range GetConfig() {
-			fmt.Printf("%s=%v\n", k, v)
+			fmt.Fprintf(output.Text, "%s=%v\n", k, MaskSecret(k, v))
 		}
 		return nil
 	},
@@ -51,14 +59,21 @@ var ConfigGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get the specified configuration file",
 	Example: `
-config get -k:Get the specified configuration file`,
+config get -k:Get the specified configuration file
+config get -k privateKey --reveal:Show a secret instead of masking it`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("config/getConfig called")
+		fmt.Fprintln(output.Text, "config/getConfig called")
 		value, err := GetConfigByKey(key)
 		if err != nil {
 			return err
 		}
-		fmt.Println("K:[", key, "] V:[", value, "]")
+		if !reveal {
+			value = MaskSecret(key, value).(string)
+		}
+		if output.Structured() {
+			return output.Print(Setting{Key: key, Value: value})
+		}
+		fmt.Fprintln(output.Text, "K:[", key, "] V:[", value, "]")
 		return err
 	},
 }
@@ -70,14 +85,14 @@ var ConfigAddCmd = &cobra.Command{
 	Example: `
 config add -k -v:Add the specified configuration file`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("config/addConfig called")
+		fmt.Fprintln(output.Text, "config/addConfig called")
 		err := AddConfig(key, value)
 		if err == nil {
 			newValue, errs := GetConfigByKey(key)
 			if errs != nil {
 				return errs
 			}
-			fmt.Println("Key:[", key, "] Value:[", newValue, "]")
+			fmt.Fprintln(output.Text, "Key:[", key, "] Value:[", MaskSecret(key, newValue), "]")
 		}
 		return err
 	},
@@ -90,14 +105,14 @@ var ConfigSetCmd = &cobra.Command{
 	Example: `
 config set -k -v:Set the specified configuration file`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("config/setConfig called")
+		fmt.Fprintln(output.Text, "config/setConfig called")
 		err := SetConfigByKey(key, value)
 		if err == nil {
 			newValue, errs := GetConfigByKey(key)
 			if errs != nil {
 				return errs
 			}
-			fmt.Println("Key:[", key, "] Value:[", newValue, "]")
+			fmt.Fprintln(output.Text, "Key:[", key, "] Value:[", MaskSecret(key, newValue), "]")
 		}
 		return err
 	},
@@ -110,7 +125,7 @@ var ConfigDelCmd = &cobra.Command{
 	Example: `
 config del -k:Delete the specified configuration file`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("config/delConfig called")
+		fmt.Fprintln(output.Text, "config/delConfig called")
 		err := DelConfigByKey(key)
 		return err
 	},
@@ -118,6 +133,20 @@ config del -k:Delete the specified configuration file`,
 
 var key string
 var value string
+var reveal bool
+
+// Setting is the structured output of config get
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Keys whose values are never printed unless asked to
+var secretKeys = map[string]bool{
+	"privatekey":         true,
+	"mnemonic":           true,
+	"mnemonicpassphrase": true,
+}
 
 func init() {
 	// Add command
@@ -125,9 +154,12 @@ func init() {
 	ConfigCmd.AddCommand(ConfigSetCmd)
 	ConfigCmd.AddCommand(ConfigDelCmd)
 	ConfigCmd.AddCommand(ConfigAddCmd)
+	ConfigCmd.AddCommand(KeystoreCmd)
+	ConfigCmd.AddCommand(ProfileCmd)
 
 	// Add flags
 	ConfigGetCmd.Flags().StringVarP(&key, "key", "k", "", "key")
+	ConfigGetCmd.Flags().BoolVar(&reveal, "reveal", false, "show secrets such as privateKey")
 	ConfigGetCmd.MarkFlagRequired("key")
 
 	ConfigAddCmd.Flags().StringVarP(&key, "key", "k", "", "key")
@@ -150,6 +182,14 @@ func GetConfig() map[string]any {
 	return viper.AllSettings()
 }
 
+// Mask the value of secret keys
+func MaskSecret(key string, value any) any {
+	if !secretKeys[strings.ToLower(key)] {
+		return value
+	}
+	return "********"
+}
+
 // Get config by key
 func GetConfigByKey(key string) (string, error) {
 	value := viper.GetString(key)
@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// KeystoreCmd represents the config/keystore command
+var KeystoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "Store the private key as an encrypted keystore file",
+	Example: `
+config keystore import:Encrypt privateKey into a keystore file`,
+}
+
+// KeystoreImportCmd represents the config/keystore/import command
+var KeystoreImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Encrypt privateKey into a keystore file and remove it from the configuration file",
+	Example: `
+config keystore import:Encrypt privateKey into ./keystore
+config keystore import -d ~/.keystore --password-file pass.txt:Choose the directory and passphrase file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "config/keystore/import called")
+		private := viper.GetString("privateKey")
+		if private == "" {
+			return errors.New("privateKey is empty")
+		}
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(private, "0x"))
+		if err != nil {
+			return err
+		}
+
+		dir := keystoreDir
+		if dir == "" {
+			dir = DefaultKeystoreDir()
+		}
+		path, err := ImportKey(privateKey, dir)
+		if err != nil {
+			return err
+		}
+
+		// Reference the keystore instead of the raw key
+		address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+		if err := AddConfig("keystore", path); err != nil {
+			return err
+		}
+		if err := AddConfig("address", address); err != nil {
+			return err
+		}
+		if err := DelConfigByKey("privateKey"); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(output.Text, "Keystore:[", path, "] Address:[", address, "]")
+		return nil
+	},
+}
+
+// Passphrase file used instead of prompting, set by --password-file
+var PasswordFile string
+
+var keystoreDir string
+
+func init() {
+	// Add command
+	KeystoreCmd.AddCommand(KeystoreImportCmd)
+
+	// Add flags
+	KeystoreImportCmd.Flags().StringVarP(&keystoreDir, "dir", "d", "", "keystore directory (default is: keystore next to the configuration file)")
+}
+
+// The keystore directory next to the configuration file
+func DefaultKeystoreDir() string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "keystore")
+}
+
+// Encrypt a private key into a Web3 Secret Storage file in dir and return its path
+func ImportKey(privateKey *ecdsa.PrivateKey, dir string) (string, error) {
+	passphrase, err := ReadPassphrase("New keystore passphrase", true)
+	if err != nil {
+		return "", err
+	}
+
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.ImportECDSA(privateKey, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return account.URL.Path, nil
+}
+
+// Read a keystore passphrase from --password-file, the passwordFile key or the terminal
+func ReadPassphrase(question string, confirm bool) (string, error) {
+	file := PasswordFile
+	if file == "" {
+		file = viper.GetString("passwordFile")
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	passphrase, err := prompt.Password(question)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		repeat, err := prompt.Password("Repeat passphrase")
+		if err != nil {
+			return "", err
+		}
+		if repeat != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,365 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ProfileCmd represents the config/profile command
+var ProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named network profiles",
+	Example: `
+config profile add sepolia --network https://.. --symbol ETH:Add a profile
+config profile list:List profiles
+config profile use sepolia:Select the default profile
+config profile remove sepolia:Remove a profile
+trade --profile sepolia:Use a profile for one command`,
+}
+
+// ProfileAddCmd represents the config/profile/add command
+var ProfileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or update a profile",
+	Example: `
+config profile add mainnet --network https://.. --explorer https://etherscan.io --symbol ETH
+config profile add sepolia --network https://.. --signer keystore/UTC--..:Use another keystore on this network`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "config/profile/add called")
+		name := args[0]
+		if !profileNameRegex.MatchString(name) {
+			return errors.New("profile names may only contain a-z, 0-9 and -")
+		}
+
+		fields := map[string]string{
+			"netWork":  profileNetwork,
+			"chainId":  profileChainID,
+			"explorer": strings.TrimRight(profileExplorer, "/"),
+			"symbol":   profileSymbol,
+		}
+
+		// Detect the chain ID when it is not given
+		if fields["chainId"] == "" && fields["netWork"] != "" {
+			fields["chainId"] = detectChainID(fields["netWork"])
+		}
+
+		// The default signer is a keystore, its address is read without unlocking it
+		if profileSigner != "" {
+			address, err := keystoreAddress(profileSigner)
+			if err != nil {
+				return err
+			}
+			fields["keystore"] = profileSigner
+			fields["address"] = address
+		}
+
+		for _, field := range ProfileFields {
+			if fields[field] == "" {
+				continue
+			}
+			if err := AddConfig(profileKey(name, field), fields[field]); err != nil {
+				return err
+			}
+		}
+		if !ProfileExists(name) {
+			return errors.New("please set at least one profile field")
+		}
+		printProfile(name, name == ActiveProfile())
+		return nil
+	},
+}
+
+// ProfileListCmd represents the config/profile/list command
+var ProfileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, the active one is marked with *",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "config/profile/list called")
+		names := ProfileNames()
+		if len(names) == 0 {
+			fmt.Fprintln(output.Text, "No profiles, add one with config profile add")
+			return nil
+		}
+		for _, name := range names {
+			printProfile(name, name == ActiveProfile())
+		}
+		return nil
+	},
+}
+
+// ProfileUseCmd represents the config/profile/use command
+var ProfileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Select the profile used when --profile is not given",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "config/profile/use called")
+		if !ProfileExists(args[0]) {
+			return fmt.Errorf("profile %s does not exist", args[0])
+		}
+		if err := AddConfig("profile", args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(output.Text, "Profile:[", args[0], "]")
+		return nil
+	},
+}
+
+// ProfileRemoveCmd represents the config/profile/remove command
+var ProfileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "config/profile/remove called")
+		name := args[0]
+		if !ProfileExists(name) {
+			return fmt.Errorf("profile %s does not exist", name)
+		}
+		for _, field := range ProfileFields {
+			if viper.Get(profileKey(name, field)) == nil {
+				continue
+			}
+			if err := DelConfigByKey(profileKey(name, field)); err != nil {
+				return err
+			}
+		}
+		if viper.GetString("profile") == name {
+			if err := DelConfigByKey("profile"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// Profile selected by --profile, overriding the profile key
+var ProfileName string
+
+// Configuration keys a profile can set
+var ProfileFields = []string{"netWork", "chainId", "keystore", "address", "explorer", "symbol"}
+
+// Underscores separate the name from the field in the configuration file
+var profileNameRegex = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+var profileNetwork string
+var profileChainID string
+var profileSigner string
+var profileExplorer string
+var profileSymbol string
+
+func init() {
+	// Add command
+	ProfileCmd.AddCommand(ProfileAddCmd)
+	ProfileCmd.AddCommand(ProfileListCmd)
+	ProfileCmd.AddCommand(ProfileUseCmd)
+	ProfileCmd.AddCommand(ProfileRemoveCmd)
+
+	// Add flags
+	ProfileAddCmd.Flags().StringVar(&profileNetwork, "network", "", "RPC URL")
+	ProfileAddCmd.Flags().StringVar(&profileChainID, "chain-id", "", "chain ID (default is read from the RPC)")
+	ProfileAddCmd.Flags().StringVar(&profileSigner, "signer", "", "keystore file of the default signer")
+	ProfileAddCmd.Flags().StringVar(&profileExplorer, "explorer", "", "block explorer URL, such as https://etherscan.io")
+	ProfileAddCmd.Flags().StringVar(&profileSymbol, "symbol", "", "native currency symbol, such as ETH")
+}
+
+// Name of the profile selected by --profile or config profile use
+func ActiveProfile() string {
+	if ProfileName != "" {
+		return ProfileName
+	}
+	return viper.GetString("profile")
+}
+
+// Check that the selected profile exists
+func CheckProfile() error {
+	if name := ActiveProfile(); name != "" && !ProfileExists(name) {
+		return fmt.Errorf("profile %s does not exist, see config profile list", name)
+	}
+	return nil
+}
+
+// Read a configuration key, preferring the value set by the active profile
+func GetString(key string) string {
+	if name := ActiveProfile(); name != "" {
+		if value := viper.GetString(profileKey(name, key)); value != "" {
+			return value
+		}
+	}
+	return viper.GetString(key)
+}
+
+// Symbol of the native currency of the active network
+func NativeSymbol() string {
+	if symbol := GetString("symbol"); symbol != "" {
+		return symbol
+	}
+	return "ETH"
+}
+
+// Make a keystore the signer of the active profile, or of the configuration file without one
+func SaveSigner(keystorePath, address string) error {
+	keystoreKey, addressKey := "keystore", "address"
+	if name := ActiveProfile(); name != "" {
+		keystoreKey, addressKey = profileKey(name, "keystore"), profileKey(name, "address")
+	}
+	if err := AddConfig(keystoreKey, keystorePath); err != nil {
+		return err
+	}
+	return AddConfig(addressKey, address)
+}
+
+// Whether any field of the profile is set
+func ProfileExists(name string) bool {
+	for _, field := range ProfileFields {
+		if viper.GetString(profileKey(name, field)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Names of every profile in the configuration file
+func ProfileNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, key := range viper.AllKeys() {
+		rest, ok := strings.CutPrefix(key, "profile_")
+		if !ok {
+			continue
+		}
+		name, _, ok := strings.Cut(rest, "_")
+		if ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileKey(name, field string) string {
+	return "profile_" + name + "_" + strings.ToLower(field)
+}
+
+// Ask the RPC for its chain ID, returning nothing when it cannot be reached
+func detectChainID(network string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := ethclient.DialContext(ctx, network)
+	if err != nil {
+		return ""
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return ""
+	}
+	return chainID.String()
+}
+
+// Connect to the network of the active profile and check its chain ID
+func DialNetwork() (*ethclient.Client, *big.Int, error) {
+	network := GetString("netWork")
+	if network == "" {
+		return nil, nil, errors.New("netWork is empty")
+	}
+
+	client, err := ethclient.Dial(network)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckChainID(chainID); err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintln(output.Text, "<-- ⛓️  Network connection successful, chainID:", chainID, "-->")
+	return client, chainID, nil
+}
+
+// Compare the chain ID of the RPC with the configured chainId, a wrong RPC would sign for another chain
+func CheckChainID(chainID *big.Int) error {
+	configured := GetString("chainId")
+	if configured == "" {
+		return nil
+	}
+	expected, ok := new(big.Int).SetString(configured, 10)
+	if !ok {
+		return errors.New("Check the chainId configured:<" + configured + ">")
+	}
+	if expected.Cmp(chainID) != 0 {
+		return fmt.Errorf("netWork is on chain %s, but chainId is %s, check the RPC of the profile", chainID, expected)
+	}
+	return nil
+}
+
+// Read the address of a keystore file without decrypting it
+func keystoreAddress(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var key struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(content, &key); err != nil || !common.IsHexAddress(key.Address) {
+		return "", errors.New("please enter a valid keystore file")
+	}
+	return common.HexToAddress(key.Address).Hex(), nil
+}
+
+// Print the fields of a profile
+func printProfile(name string, active bool) {
+	marker := " "
+	if active {
+		marker = "*"
+	}
+	fmt.Fprintf(output.Text, "%s %s\n", marker, name)
+	for _, field := range ProfileFields {
+		if value := viper.GetString(profileKey(name, field)); value != "" {
+			fmt.Fprintf(output.Text, "    %-8s: %s\n", field, value)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Format selected by --output
+var Format = FormatText
+
+// Objects are written here
+var stdout io.Writer = os.Stdout
+
+// Decorated text is written here, stderr in structured formats so stdout only holds objects
+var Text io.Writer = os.Stdout
+
+// Check the selected format
+func CheckFormat() error {
+	switch Format {
+	case FormatText, FormatJSON, FormatYAML:
+		return nil
+	}
+	return errors.New("Check the output entered:<" + Format + ">, use json/yaml/text")
+}
+
+// Whether objects are printed instead of decorated text
+func Structured() bool {
+	return Format == FormatJSON || Format == FormatYAML
+}
+
+// Keep stdout for objects only, decorated text goes to stderr
+func Redirect() {
+	if Structured() {
+		TextToStderr()
+	}
+}
+
+// Send decorated text to stderr, for commands whose result is piped from stdout
+func TextToStderr() {
+	Text = os.Stderr
+}
+
+// Write a result that is already encoded, such as a raw transaction, to stdout
+func Write(content string) error {
+	_, err := fmt.Fprintln(stdout, content)
+	return err
+}
+
+// Fields added by commands that report in several steps, in the order they were added
+var reportKeys []string
+var reportFields = map[string]any{}
+
+// Add a field to the report, so a command reporting in several steps prints a single object
+func Add(key string, v any) {
+	if _, ok := reportFields[key]; !ok {
+		reportKeys = append(reportKeys, key)
+	}
+	reportFields[key] = v
+}
+
+// Print the report built with Add, if any, as one object
+func Flush() error {
+	if len(reportKeys) == 0 {
+		return nil
+	}
+
+	// A map would sort the fields, build the object to keep the order of the steps
+	report := []byte("{")
+	for i, key := range reportKeys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(reportFields[key])
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			report = append(report, ',')
+		}
+		report = append(append(append(report, name...), ':'), value...)
+	}
+	report = append(report, '}')
+
+	reportKeys, reportFields = nil, map[string]any{}
+	return Print(json.RawMessage(report))
+}
+
+// Print an object in the selected format, field names come from the json tags
+func Print(v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if Format != FormatYAML {
+		_, err = fmt.Fprintln(stdout, string(content))
+		return err
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	if _, err := fmt.Fprintln(stdout, "---"); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Drop the flow style inherited from JSON
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	output "txtoolbox/cmd/output"
+
+	"golang.org/x/term"
+)
+
+// Policies used to answer prompts without asking
+const (
+	PolicyAsk      = "ask"
+	PolicyEstimate = "estimate"
+	PolicyInput    = "input"
+	PolicyCreate   = "create"
+	PolicyContinue = "continue"
+	PolicyWarn     = "warn"
+	PolicyFail     = "fail"
+)
+
+// Never prompt, every decision comes from a policy or fails
+var NonInteractive bool
+
+// Answer yes to every confirmation
+var AssumeYes bool
+
+// What to do when an input is lower than the estimate
+var LowInputPolicy = PolicyAsk
+
+// What to do when the configuration file does not exist
+var MissingConfigPolicy = PolicyAsk
+
+// What to do when the recipient looks like a saved address, --yes does not accept it
+var LookalikePolicy = PolicyAsk
+
+// What to do when a mixed-case address fails its checksum
+var BadChecksumPolicy = PolicyFail
+
+// Returned when a prompt would be needed but prompts are disabled
+var ErrDecisionRequired = errors.New("a decision is required but prompts are disabled")
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// Check the policy flags
+func CheckPolicies() error {
+	switch LowInputPolicy {
+	case PolicyAsk, PolicyEstimate, PolicyInput, PolicyFail:
+	default:
+		return fmt.Errorf("invalid --on-low-input policy <%s>, use ask/estimate/input/fail", LowInputPolicy)
+	}
+
+	switch MissingConfigPolicy {
+	case PolicyAsk, PolicyCreate, PolicyFail:
+	default:
+		return fmt.Errorf("invalid --on-missing-config policy <%s>, use ask/create/fail", MissingConfigPolicy)
+	}
+
+	switch LookalikePolicy {
+	case PolicyAsk, PolicyContinue, PolicyFail:
+	default:
+		return fmt.Errorf("invalid --on-lookalike policy <%s>, use ask/continue/fail", LookalikePolicy)
+	}
+
+	switch BadChecksumPolicy {
+	case PolicyWarn, PolicyFail:
+	default:
+		return fmt.Errorf("invalid --on-bad-checksum policy <%s>, use warn/fail", BadChecksumPolicy)
+	}
+	return nil
+}
+
+// Whether prompts may be shown
+func Interactive() bool {
+	return !NonInteractive && !AssumeYes
+}
+
+// Read one line from the terminal
+func ReadLine(question string) (string, error) {
+	if !Interactive() {
+		return "", fmt.Errorf("%w: %s", ErrDecisionRequired, question)
+	}
+
+	fmt.Fprintln(output.Text, question)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		// Stdin is closed, nobody can answer
+		return "", fmt.Errorf("%w: %s", ErrDecisionRequired, question)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Ask a yes/no question, --yes answers it and --non-interactive fails it
+func Confirm(question string) (bool, error) {
+	if AssumeYes {
+		fmt.Fprintln(output.Text, question, "-> Y (--yes)")
+		return true, nil
+	}
+	if !Interactive() {
+		return false, fmt.Errorf("%w: %s", ErrDecisionRequired, question)
+	}
+
+	for {
+		next, err := ReadLine(question + " (Y/y/N/n)")
+		if err != nil {
+			return false, err
+		}
+
+		switch next {
+		case "Y", "y":
+			return true, nil
+		case "N", "n":
+			return false, nil
+		default:
+			fmt.Fprintln(output.Text, "Please enter Y/y or N/n")
+		}
+	}
+}
+
+// Answer a yes/no question from a policy, only asking when the policy is ask
+func Decide(question, policy, yesPolicy, noPolicy string) (bool, error) {
+	switch policy {
+	case PolicyFail:
+		return false, fmt.Errorf("%w: %s", ErrDecisionRequired, question)
+	case yesPolicy:
+		fmt.Fprintln(output.Text, question, "->", policy)
+		return true, nil
+	case noPolicy:
+		fmt.Fprintln(output.Text, question, "->", policy)
+		return false, nil
+	default:
+		return Confirm(question)
+	}
+}
+
+// Read a secret from the terminal without echoing it
+func Password(question string) (string, error) {
+	if !Interactive() {
+		return "", fmt.Errorf("%w: %s", ErrDecisionRequired, question)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return ReadLine(question + ":")
+	}
+
+	fmt.Fprint(output.Text, question+": ")
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(output.Text)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
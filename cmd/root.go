@@ -26,6 +26,9 @@ import (
 	"os"
 	"strings"
 	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
 	transaction "txtoolbox/cmd/transaction"
 	utils "txtoolbox/cmd/utils"
 
@@ -50,6 +53,12 @@ transaction :Transaction
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+
+	// Commands reporting in several steps print their object once they end, also when they fail
+	if flushErr := output.Flush(); flushErr != nil {
+		fmt.Fprintln(os.Stderr, flushErr)
+		os.Exit(1)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -68,12 +77,35 @@ func init() {
 
 	// Add flags
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "specify a configuration file (default is: ./.config.env)")
+	rootCmd.PersistentFlags().StringVar(&output.Format, "output", output.FormatText, "output format: json/yaml/text")
+	rootCmd.PersistentFlags().StringVar(&config.ProfileName, "profile", "", "named network profile to use (default is the profile key)")
+	rootCmd.PersistentFlags().StringVar(&signer.AccountIndex, "account-index", "", "account index N of the mnemonic derivation path (default is the accountIndex key, or 0)")
+	rootCmd.PersistentFlags().StringVar(&config.PasswordFile, "password-file", "", "file holding the keystore passphrase")
+	rootCmd.PersistentFlags().BoolVarP(&prompt.AssumeYes, "yes", "y", false, "answer yes to every confirmation")
+	rootCmd.PersistentFlags().BoolVar(&prompt.NonInteractive, "non-interactive", false, "never prompt, exit with an error when a decision is required")
+	rootCmd.PersistentFlags().StringVar(&prompt.LowInputPolicy, "on-low-input", prompt.PolicyAsk, "when an input is lower than the estimate: ask/estimate/input/fail")
+	rootCmd.PersistentFlags().StringVar(&prompt.LookalikePolicy, "on-lookalike", prompt.PolicyAsk, "when the recipient looks like a saved address: ask/continue/fail")
+	rootCmd.PersistentFlags().StringVar(&prompt.BadChecksumPolicy, "on-bad-checksum", prompt.PolicyFail, "when a mixed-case address fails its EIP-55 checksum: warn/fail")
+	rootCmd.PersistentFlags().StringVar(&prompt.MissingConfigPolicy, "on-missing-config", prompt.PolicyAsk, "when the configuration file does not exist: ask/create/fail")
 
 	//Disabling Default Commands
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
 
 func initConfig() {
+	// Check prompt policies
+	if err := prompt.CheckPolicies(); err != nil {
+		fmt.Fprintln(output.Text, err)
+		os.Exit(1)
+	}
+
+	// Check output format
+	if err := output.CheckFormat(); err != nil {
+		fmt.Fprintln(output.Text, err)
+		os.Exit(1)
+	}
+	output.Redirect()
+
 	// Check if config file exists
 	if cfgFile != "" {
 		if !strings.Contains(cfgFile, ".env") {
@@ -87,24 +119,27 @@ func initConfig() {
 
 	// Read in config file
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(output.Text, "Using config file:", viper.ConfigFileUsed())
 	} else {
-		for {
-			fmt.Println("Create configuration file? (Y/y/N/n)")
-
-			var confirm string
-			fmt.Scanln(&confirm)
-
-			switch confirm {
-			case "Y", "y":
-				viper.WriteConfigAs(cfgFile)
-				fmt.Println("Configuration file created.")
-				return
-			case "N", "n":
-				os.Exit(0)
-			default:
-				fmt.Println("Please enter Y/y or N/n")
-			}
+		create, err := prompt.Decide("Create configuration file?", prompt.MissingConfigPolicy, prompt.PolicyCreate, "")
+		if err != nil {
+			fmt.Fprintln(output.Text, err)
+			os.Exit(1)
+		}
+		if !create {
+			os.Exit(0)
 		}
+
+		viper.WriteConfigAs(cfgFile)
+		fmt.Fprintln(output.Text, "Configuration file created.")
+	}
+
+	// Check the selected profile
+	if err := config.CheckProfile(); err != nil {
+		fmt.Fprintln(output.Text, err)
+		os.Exit(1)
+	}
+	if profile := config.ActiveProfile(); profile != "" {
+		fmt.Fprintln(output.Text, "Using profile:", profile)
 	}
 }
@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// BIP-44 path of Ethereum accounts, N is replaced by the account index
+const DefaultDerivationPath = "m/44'/60'/0'/0/N"
+
+// Account index selected by --account-index, the accountIndex key is used when empty
+var AccountIndex string
+
+// Order of the secp256k1 group
+var secp256k1N = crypto.S256().Params().N
+
+// The account index of this command
+func accountIndex() (uint32, error) {
+	index := AccountIndex
+	if index == "" {
+		index = viper.GetString("accountIndex")
+	}
+	if index == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(index, 10, 31)
+	if err != nil {
+		return 0, errors.New("Check the account index entered:<" + index + ">")
+	}
+	return uint32(n), nil
+}
+
+// Resolve a derivation path template, N is replaced by the account index
+func DerivationPath(template string, index uint32) (accounts.DerivationPath, error) {
+	if template == "" {
+		template = DefaultDerivationPath
+	}
+	path, err := accounts.ParseDerivationPath(strings.ReplaceAll(template, "N", strconv.FormatUint(uint64(index), 10)))
+	if err != nil {
+		return nil, errors.New("Check the derivation path entered:<" + template + ">")
+	}
+	return path, nil
+}
+
+// Normalise the words of a mnemonic and check its BIP-39 checksum
+func NormalizeMnemonic(mnemonic string) (string, error) {
+	mnemonic = strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", errors.New("please enter a valid BIP-39 mnemonic")
+	}
+	return mnemonic, nil
+}
+
+// The BIP-39 seed of a mnemonic and its optional passphrase
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// Derive the BIP-32 private key of a path from a seed
+func DeriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	// Master key
+	key, chainCode, err := splitHMAC([]byte("Bitcoin seed"), seed, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range path {
+		var data []byte
+		if child >= 0x80000000 {
+			// Hardened children commit to the private key
+			data = append([]byte{0}, common.LeftPadBytes(key.Bytes(), 32)...)
+		} else {
+			privateKey, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&privateKey.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, child)
+
+		key, chainCode, err = splitHMAC(chainCode, data, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+}
+
+// Split HMAC-SHA512 into a key added to the parent key and a chain code
+func splitHMAC(hmacKey, data []byte, parent *big.Int) (*big.Int, []byte, error) {
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Cmp(secp256k1N) >= 0 {
+		return nil, nil, errors.New("invalid derived key, use another index")
+	}
+	if parent != nil {
+		key.Add(key, parent).Mod(key, secp256k1N)
+	}
+	if key.Sign() == 0 {
+		return nil, nil, errors.New("invalid derived key, use another index")
+	}
+	return key, sum[32:], nil
+}
+
+// Derive the configured account from the mnemonic key
+func mnemonicKey(quiet bool) (*ecdsa.PrivateKey, error) {
+	index, err := accountIndex()
+	if err != nil {
+		return nil, err
+	}
+	path, err := DerivationPath(viper.GetString("derivationPath"), index)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := MnemonicSeed(viper.GetString("mnemonic"), viper.GetString("mnemonicPassphrase"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !quiet {
+		fmt.Fprintln(output.Text, "<-- ⚠️  mnemonic is stored in plaintext, keep", viper.ConfigFileUsed(), "private -->")
+		fmt.Fprintln(output.Text, "<-- 🌱 Mnemonic account:", path, "-->")
+	}
+	return DeriveKey(seed, path)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Test vector 1 of BIP-32, the extended private keys are checked with their base58check checksum
+func TestDeriveKeyBIP32Vector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	vectors := []struct {
+		path accounts.DerivationPath
+		xprv string
+	}{
+		{accounts.DerivationPath{}, "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+		{accounts.DerivationPath{0x80000000}, "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"},
+		{accounts.DerivationPath{0x80000000, 1}, "xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs"},
+		{accounts.DerivationPath{0x80000000, 1, 0x80000002}, "xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM"},
+		{accounts.DerivationPath{0x80000000, 1, 0x80000002, 2}, "xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334"},
+		{accounts.DerivationPath{0x80000000, 1, 0x80000002, 2, 1000000000}, "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"},
+	}
+	for _, vector := range vectors {
+		want := xprvKey(t, vector.xprv)
+		key, err := DeriveKey(seed, vector.path)
+		if err != nil {
+			t.Fatalf("%s: %v", vector.path, err)
+		}
+		if got := crypto.FromECDSA(key); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", vector.path, got, want)
+		}
+	}
+}
+
+// The default Hardhat and Anvil accounts
+func TestMnemonicAddresses(t *testing.T) {
+	mnemonic := "test test test test test test test test test test test junk"
+	want := []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	}
+	// Extra spaces and upper case are normalised away
+	seed, err := MnemonicSeed("  "+strings.ToUpper(mnemonic), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, address := range want {
+		path, err := DerivationPath(DefaultDerivationPath, uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := DeriveKey(seed, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey); got != common.HexToAddress(address) {
+			t.Errorf("account %d: got %s, want %s", i, got.Hex(), address)
+		}
+	}
+}
+
+func TestMnemonicSeedRejectsBadChecksum(t *testing.T) {
+	if _, err := MnemonicSeed("test test test test test test test test test test test test", ""); err == nil {
+		t.Error("a mnemonic with a bad checksum was accepted")
+	}
+}
+
+// The private key of a base58check encoded extended private key
+func xprvKey(t *testing.T, xprv string) []byte {
+	t.Helper()
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	n := new(big.Int)
+	for _, c := range xprv {
+		n.Mul(n, big.NewInt(58)).Add(n, big.NewInt(int64(strings.IndexRune(alphabet, c))))
+	}
+	raw := n.Bytes()
+	if len(raw) != 82 {
+		t.Fatalf("%s: %d bytes, want 82", xprv, len(raw))
+	}
+	payload, checksum := raw[:78], raw[78:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		t.Fatalf("%s: bad checksum", xprv)
+	}
+	// version, depth, fingerprint, child number, chain code, then 0x00 and the key
+	return payload[46:]
+}
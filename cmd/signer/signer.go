@@ -0,0 +1,126 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+)
+
+// Load the configured signing key, from the keystore file, the mnemonic or the plaintext privateKey
+func LoadKey() (*ecdsa.PrivateKey, error) {
+	if path := config.GetString("keystore"); path != "" {
+		return loadKeystore(path)
+	}
+
+	if viper.GetString("mnemonic") != "" {
+		return mnemonicKey(false)
+	}
+
+	if private := viper.GetString("privateKey"); private != "" {
+		fmt.Fprintln(output.Text, "<-- ⚠️  privateKey is stored in plaintext, run config keystore import to encrypt it -->")
+		return ParsePrivateKey(private)
+	}
+
+	return nil, errors.New("no signer configured, set keystore, mnemonic or privateKey")
+}
+
+// Signer keys in the order LoadKey uses them, the first one set is the signer
+var SignerKeys = []string{"keystore", "mnemonic", "privateKey"}
+
+// The signer key that LoadKey uses, empty when none is set
+func ConfiguredSigner() string {
+	if config.GetString("keystore") != "" {
+		return "keystore"
+	}
+	for _, key := range SignerKeys[1:] {
+		if viper.GetString(key) != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// Parse a hex private key with or without 0x
+func ParsePrivateKey(private string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(private), "0x"))
+	if err != nil {
+		return nil, errors.New("please enter a valid private key")
+	}
+	return privateKey, nil
+}
+
+// The configured account address, known without unlocking the keystore
+func ConfiguredAddress() (common.Address, bool) {
+	if address := config.GetString("address"); common.IsHexAddress(address) {
+		return common.HexToAddress(address), true
+	}
+
+	if viper.GetString("mnemonic") != "" {
+		privateKey, err := mnemonicKey(true)
+		if err == nil {
+			return crypto.PubkeyToAddress(privateKey.PublicKey), true
+		}
+	}
+
+	if private := viper.GetString("privateKey"); private != "" {
+		privateKey, err := ParsePrivateKey(private)
+		if err == nil {
+			return crypto.PubkeyToAddress(privateKey.PublicKey), true
+		}
+	}
+	return common.Address{}, false
+}
+
+// Decrypt a Web3 Secret Storage file
+func loadKeystore(path string) (*ecdsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := config.ReadPassphrase("Keystore passphrase", false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(content, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	// The configured address guards against pointing at the wrong file
+	if address := config.GetString("address"); address != "" && common.HexToAddress(address) != key.Address {
+		return nil, fmt.Errorf("keystore %s holds %s, not the configured address %s", path, key.Address.Hex(), address)
+	}
+	return key.PrivateKey, nil
+}
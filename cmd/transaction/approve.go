@@ -0,0 +1,306 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+// ApproveCmd represents the transaction/approve command
+var ApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Approve a spender for ERC-20 tokens, an ERC-721 token or all NFTs of a collection",
+	Example: `
+trade approve --token 0xA0b8..eB48 --spender 0x.. --amount 100:Set the ERC-20 allowance to 100 tokens
+trade approve --token 0xA0b8..eB48 --spender 0x.. --amount 50 --increase:Raise the allowance by 50 with increaseAllowance
+trade approve --token 0xNFT --spender 0x.. --token-id 42:Approve one ERC-721 token
+trade approve --token 0xNFT --spender 0x.. --all:Approve an operator for all ERC-721/ERC-1155 tokens`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/approve called")
+		if approveIncrease && approveAmount == "" && !approveUnlimited {
+			return errors.New("--increase needs an --amount or --unlimited")
+		}
+		return sendApproval(false)
+	},
+}
+
+// RevokeCmd represents the transaction/revoke command
+var RevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke an ERC-20 allowance, an ERC-721 token approval or an NFT operator",
+	Example: `
+trade revoke --token 0xA0b8..eB48 --spender 0x..:Set the ERC-20 allowance to 0
+trade revoke --token 0xNFT --token-id 42:Clear the approval of one ERC-721 token
+trade revoke --token 0xNFT --spender 0x.. --all:Remove an ERC-721/ERC-1155 operator`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/revoke called")
+		return sendApproval(true)
+	},
+}
+
+var approveToken string
+var approveSpender string
+var approveAmount string
+var approveUnlimited bool
+var approveIncrease bool
+var approveAll bool
+var approveTokenID string
+
+func init() {
+	// Add flags
+	ApproveCmd.Flags().StringVar(&approveToken, "token", "", "token contract address")
+	ApproveCmd.Flags().StringVar(&approveSpender, "spender", "", "spender or operator to approve")
+	ApproveCmd.Flags().StringVarP(&approveAmount, "amount", "a", "", "ERC-20 amount in whole tokens, such as 12.5")
+	ApproveCmd.Flags().BoolVar(&approveUnlimited, "unlimited", false, "approve the maximum uint256 ERC-20 amount")
+	ApproveCmd.Flags().BoolVar(&approveIncrease, "increase", false, "use increaseAllowance to add to the current ERC-20 allowance")
+	ApproveCmd.Flags().BoolVar(&approveAll, "all", false, "approve an operator for all tokens with setApprovalForAll")
+	ApproveCmd.Flags().StringVar(&approveTokenID, "token-id", "", "ERC-721 token ID to approve")
+	ApproveCmd.MarkFlagRequired("token")
+	ApproveCmd.MarkFlagRequired("spender")
+	ApproveCmd.MarkFlagsOneRequired("amount", "unlimited", "all", "token-id")
+	ApproveCmd.MarkFlagsMutuallyExclusive("amount", "unlimited", "all", "token-id")
+
+	RevokeCmd.Flags().StringVar(&approveToken, "token", "", "token contract address")
+	RevokeCmd.Flags().StringVar(&approveSpender, "spender", "", "spender or operator to revoke")
+	RevokeCmd.Flags().BoolVar(&approveAll, "all", false, "revoke an operator approved with setApprovalForAll")
+	RevokeCmd.Flags().StringVar(&approveTokenID, "token-id", "", "ERC-721 token ID whose approval is cleared")
+	RevokeCmd.MarkFlagRequired("token")
+	RevokeCmd.MarkFlagsMutuallyExclusive("all", "token-id")
+}
+
+// Build the approve, increaseAllowance or setApprovalForAll call and send it through the usual checks
+func sendApproval(revoke bool) error {
+	token, err := utils.ParseAddress(approveToken)
+	if err != nil {
+		return err
+	}
+	// Clearing a single ERC-721 approval does not need the spender
+	var spender common.Address
+	if approveSpender != "" {
+		if spender, err = utils.ParseAddress(approveSpender); err != nil {
+			return err
+		}
+	} else if !revoke || approveTokenID == "" {
+		return errors.New("please enter a valid spender with --spender")
+	}
+	var tokenID *big.Int
+	if approveTokenID != "" {
+		var ok bool
+		if tokenID, ok = new(big.Int).SetString(approveTokenID, 10); !ok || tokenID.Sign() < 0 {
+			return errors.New("Check the token ID entered:<" + approveTokenID + ">")
+		}
+	}
+
+	trade, err := readInConfig()
+	if err != nil {
+		return err
+	}
+	client, _, err := config.DialNetwork()
+	if err != nil {
+		return err
+	}
+	privateKey, err := signer.LoadKey()
+	if err != nil {
+		return err
+	}
+	trade.Key = privateKey
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	var approval *Approval
+	switch {
+	case approveAll:
+		approval, err = operatorApproval(client, token, owner, spender, !revoke)
+	case tokenID != nil:
+		approval, err = tokenApproval(client, token, owner, spender, tokenID, revoke)
+	default:
+		approval, err = allowanceApproval(client, token, owner, spender, revoke)
+	}
+	if err != nil {
+		return err
+	}
+	printApproval(approval)
+	if revoke && !approval.Active {
+		fmt.Fprintln(output.Text, "<-- ✅ Nothing to revoke -->")
+		return nil
+	}
+	if !revoke {
+		if err := checkRecipients(spender); err != nil {
+			return err
+		}
+	}
+
+	trade.To = &token
+	trade.Data = approval.Data
+	trade.Amount, trade.AmountUnit = "0", "wei"
+	return processConfig(trade)
+}
+
+// An approval change with the current and the new state for display
+type Approval struct {
+	Token    common.Address
+	Symbol   string
+	Standard string
+	Spender  common.Address
+	Current  string
+	New      string
+	Active   bool
+	Data     []byte
+}
+
+// Set, raise or clear an ERC-20 allowance
+func allowanceApproval(client *ethclient.Client, token, owner, spender common.Address, revoke bool) (*Approval, error) {
+	// Revoking does not need decimals(), amounts are then shown in the smallest unit
+	var decimals uint8
+	symbol := utils.ReadSymbol(client, token)
+	unit := symbol
+	result, err := utils.CallMethod(client, token, utils.ERC20Decimals)
+	switch {
+	case err == nil:
+		decimals = result[0].(uint8)
+	case revoke:
+		unit += " (raw, decimals() is missing)"
+	default:
+		return nil, fmt.Errorf("decimals(): %v", err)
+	}
+	current, err := utils.CallMethod(client, token, utils.ERC20Allowance, owner, spender)
+	if err != nil {
+		return nil, fmt.Errorf("allowance(): %v", err)
+	}
+	allowance := current[0].(*big.Int)
+
+	value := new(big.Int)
+	switch {
+	case approveUnlimited:
+		value = math.MaxBig256
+	case !revoke:
+		if value, err = utils.ParseUnits(approveAmount, decimals); err != nil {
+			return nil, err
+		}
+	}
+
+	approval := &Approval{
+		Token:    token,
+		Symbol:   symbol,
+		Standard: "erc20",
+		Spender:  spender,
+		Current:  utils.FormatAllowance(allowance, decimals, unit),
+		New:      utils.FormatAllowance(value, decimals, unit),
+		Active:   allowance.Sign() > 0,
+	}
+	if approveIncrease {
+		approval.New = "+" + approval.New
+		approval.Data, err = utils.PackCall(utils.ERC20IncreaseAllowance, spender, value)
+		return approval, err
+	}
+	// Tokens such as USDT revert when an allowance is changed from one non-zero value to another
+	if !revoke && allowance.Sign() > 0 && value.Sign() > 0 {
+		fmt.Fprintln(output.Text, "<-- ⚠️  The allowance is not zero, some tokens require revoking it before approving a new amount -->")
+	}
+	approval.Data, err = utils.PackCall(utils.ERC20Approve, spender, value)
+	return approval, err
+}
+
+// Approve or clear the approval of a single ERC-721 token, clearing approves the zero address
+func tokenApproval(client *ethclient.Client, token, owner, spender common.Address, tokenID *big.Int, revoke bool) (*Approval, error) {
+	holder, err := utils.CallMethod(client, token, utils.ERC721OwnerOf, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("ownerOf(): %v", err)
+	}
+	if holder[0].(common.Address) != owner {
+		return nil, fmt.Errorf("token %s is owned by %s, not %s", tokenID, holder[0].(common.Address).Hex(), owner.Hex())
+	}
+	approved, err := utils.CallMethod(client, token, utils.ERC721GetApproved, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("getApproved(): %v", err)
+	}
+	current := approved[0].(common.Address)
+
+	approval := &Approval{
+		Token:    token,
+		Symbol:   utils.ReadSymbol(client, token),
+		Standard: "erc721",
+		Spender:  current,
+		Current:  "token " + tokenID.String() + " approved to " + current.Hex(),
+		Active:   current != (common.Address{}),
+	}
+	target := common.Address{}
+	if revoke {
+		approval.New = "token " + tokenID.String() + " not approved"
+	} else {
+		target = spender
+		approval.Spender = spender
+		approval.New = "token " + tokenID.String() + " approved to " + spender.Hex()
+	}
+	if !approval.Active {
+		approval.Current = "token " + tokenID.String() + " not approved"
+	}
+	approval.Data, err = utils.PackCall(utils.ERC20Approve, target, tokenID)
+	return approval, err
+}
+
+// Approve or remove an operator for all tokens of an ERC-721 or ERC-1155 collection
+func operatorApproval(client *ethclient.Client, token, owner, operator common.Address, approved bool) (*Approval, error) {
+	current, err := utils.CallMethod(client, token, utils.IsApprovedForAll, owner, operator)
+	if err != nil {
+		return nil, fmt.Errorf("isApprovedForAll(): %v", err)
+	}
+	state := map[bool]string{true: "operator for all tokens", false: "not an operator"}
+	approval := &Approval{
+		Token:    token,
+		Symbol:   utils.ReadSymbol(client, token),
+		Standard: utils.OperatorStandard(client, token),
+		Spender:  operator,
+		Current:  state[current[0].(bool)],
+		New:      state[approved],
+		Active:   current[0].(bool),
+	}
+	approval.Data, err = utils.PackCall(utils.SetApprovalForAll, operator, approved)
+	return approval, err
+}
+
+// Print the approval change before it goes through the transaction checks
+func printApproval(approval *Approval) {
+	spenderColor, _ := utils.GenAddressColor(approval.Spender.Hex())
+	fmt.Fprintln(output.Text, "╔═══════════[ 🔐 Approval configuration ]═══════════╗")
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "token", approval.Token.Hex())
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "symbol", approval.Symbol)
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "standard", approval.Standard)
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "spender", spenderColor)
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "current", approval.Current)
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "new", approval.New)
+	fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════════════╝")
+	if approveUnlimited || approveAll && approval.New == "operator for all tokens" {
+		fmt.Fprintln(output.Text, "<-- ⚠️  The spender can move every token you hold now and later, until it is revoked -->")
+	}
+}
@@ -0,0 +1,542 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+// Status of a row in the results file
+const (
+	batchPending  = "pending"
+	batchSent     = "sent"
+	batchSuccess  = "success"
+	batchReverted = "reverted"
+	batchReplaced = "replaced"
+	batchFailed   = "failed"
+	batchSkipped  = "skipped"
+)
+
+// BatchCmd represents the transaction/batch command
+var BatchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Send transfers to many recipients from a CSV or JSON manifest",
+	Example: `
+trade batch -f payouts.csv:Pay every row of to,amount,unit
+trade batch -f payouts.json --concurrency 8:Keep up to 8 transactions in flight
+trade batch -f payouts.csv --results out.json:Choose the results file, run again to resume`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/batch called")
+		return runBatch()
+	},
+}
+
+// One transfer of the manifest and its outcome
+type BatchRow struct {
+	Row    int     `json:"row"`
+	To     string  `json:"to"`
+	Amount string  `json:"amount"`
+	Unit   string  `json:"unit"`
+	Wei    string  `json:"wei"`
+	Nonce  *uint64 `json:"nonce,omitempty"`
+	Hash   string  `json:"hash,omitempty"`
+	Status string  `json:"status"`
+	Error  string  `json:"error,omitempty"`
+	gas    uint64
+}
+
+var batchFile string
+var batchResults string
+var batchUnit string
+var batchConcurrency int
+
+func init() {
+	// Add flags
+	BatchCmd.Flags().StringVarP(&batchFile, "file", "f", "", "CSV (to,amount,unit) or JSON manifest")
+	BatchCmd.Flags().StringVar(&batchResults, "results", "", "results file, rows already paid are skipped when it exists (default is: <file>.results.json)")
+	BatchCmd.Flags().StringVarP(&batchUnit, "unit", "u", "wei", "unit of rows that have none")
+	BatchCmd.Flags().IntVar(&batchConcurrency, "concurrency", 4, "maximum number of transactions waiting for a receipt")
+	BatchCmd.MarkFlagRequired("file")
+}
+
+// Load the manifest, resume from the results file and send every unpaid row
+func runBatch() error {
+	if batchConcurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+	rows, err := loadManifest(batchFile)
+	if err != nil {
+		return err
+	}
+	resultsPath := batchResults
+	if resultsPath == "" {
+		resultsPath = batchFile + ".results.json"
+	}
+	if err := mergeResults(rows, resultsPath); err != nil {
+		return err
+	}
+
+	template, err := readInConfig()
+	if err != nil {
+		return err
+	}
+	client, chainID, err := config.DialNetwork()
+	if err != nil {
+		return err
+	}
+	privateKey, err := signer.LoadKey()
+	if err != nil {
+		return err
+	}
+	template.ChainId = chainID
+	template.Key = privateKey
+	template.FromAddress = crypto.PubkeyToAddress(privateKey.PublicKey)
+	template.Data = nil
+
+	// Check rows sent by an earlier run before sending anything again
+	if err := reconcileRows(client, rows, template.FromAddress); err != nil {
+		return err
+	}
+	var todo []*BatchRow
+	for _, row := range rows {
+		switch row.Status {
+		case batchPending, batchFailed, batchSkipped:
+			row.Status, row.Error = batchPending, ""
+			todo = append(todo, row)
+		}
+	}
+	if len(todo) == 0 {
+		fmt.Fprintln(output.Text, "<-- ✅ Every row is already sent -->")
+		return saveResults(rows, resultsPath)
+	}
+
+	// Check gas fees once for the whole batch
+	baseFee, err := nextBaseFee(client)
+	if err != nil {
+		return err
+	}
+	if baseFee == nil {
+		err = checkGasPrice(client, template)
+	} else {
+		err = checkDynamicFees(client, template, baseFee)
+	}
+	if err != nil {
+		return err
+	}
+
+	total, err := estimateBatch(client, template, todo)
+	if err != nil {
+		return err
+	}
+	balance, err := client.PendingBalanceAt(context.Background(), template.FromAddress)
+	if err != nil {
+		return err
+	}
+	printBatchSummary(rows, todo, total, balance)
+	if total.Cmp(balance) > 0 {
+		return errors.New("insufficient balance for the batch")
+	}
+
+	recipients := make([]common.Address, len(todo))
+	for i, row := range todo {
+		recipients[i] = common.HexToAddress(row.To)
+	}
+	if err := checkRecipients(recipients...); err != nil {
+		return err
+	}
+
+	start, err := prompt.Confirm("Send batch?")
+	if err != nil {
+		return err
+	}
+	if !start {
+		os.Exit(0)
+	}
+
+	sendErr := sendBatch(client, template, todo, rows, resultsPath)
+	if err := reportBatch(rows, resultsPath); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// Read recipients, amounts and units from a CSV or JSON manifest
+func loadManifest(path string) ([]*BatchRow, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries [][3]string
+	if trimmed := bytes.TrimSpace(content); bytes.HasPrefix(trimmed, []byte("[")) {
+		var list []struct {
+			To     string      `json:"to"`
+			Amount json.Number `json:"amount"`
+			Unit   string      `json:"unit"`
+		}
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			entries = append(entries, [3]string{item.To, item.Amount.String(), item.Unit})
+		}
+	} else {
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		entries, err = csvEntries(records)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]*BatchRow, 0, len(entries))
+	for i, entry := range entries {
+		to, amount, unit := strings.TrimSpace(entry[0]), strings.TrimSpace(entry[1]), strings.TrimSpace(entry[2])
+		if unit == "" {
+			unit = batchUnit
+		}
+		if _, err := utils.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+		wei, err := utils.ToWei(amount, unit)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		}
+		rows = append(rows, &BatchRow{
+			Row:    i + 1,
+			To:     common.HexToAddress(to).Hex(),
+			Amount: amount,
+			Unit:   unit,
+			Wei:    wei.String(),
+			Status: batchPending,
+		})
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the manifest has no rows")
+	}
+	return rows, nil
+}
+
+// Map CSV records to to/amount/unit, using the header when there is one
+func csvEntries(records [][]string) ([][3]string, error) {
+	columns := [3]int{0, 1, 2}
+	if len(records) > 0 && len(records[0]) > 0 && !common.IsHexAddress(strings.TrimSpace(records[0][0])) {
+		columns = [3]int{-1, -1, -1}
+		for i, name := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "to", "address", "recipient":
+				columns[0] = i
+			case "amount", "value":
+				columns[1] = i
+			case "unit":
+				columns[2] = i
+			}
+		}
+		if columns[0] < 0 || columns[1] < 0 {
+			return nil, errors.New("the CSV header needs to and amount columns")
+		}
+		records = records[1:]
+	}
+
+	var entries [][3]string
+	for _, record := range records {
+		var entry [3]string
+		for i, column := range columns {
+			if column >= 0 && column < len(record) {
+				entry[i] = record[column]
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Copy the outcome of an earlier run from the results file
+func mergeResults(rows []*BatchRow, path string) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var previous []*BatchRow
+	if err := json.Unmarshal(content, &previous); err != nil {
+		return err
+	}
+	if len(previous) != len(rows) {
+		return fmt.Errorf("results file %s does not match the manifest", path)
+	}
+	for i, row := range rows {
+		old := previous[i]
+		if old.To != row.To || old.Wei != row.Wei {
+			return fmt.Errorf("results file %s does not match row %d of the manifest", path, row.Row)
+		}
+		row.Nonce, row.Hash, row.Status, row.Error = old.Nonce, old.Hash, old.Status, old.Error
+	}
+	fmt.Fprintln(output.Text, "<-- 📂 Resuming from", path, "-->")
+	return nil
+}
+
+// Update rows sent by an earlier run with their receipts
+func reconcileRows(client *ethclient.Client, rows []*BatchRow, from common.Address) error {
+	for _, row := range rows {
+		if row.Hash == "" || (row.Status != batchSent && row.Status != batchFailed) {
+			continue
+		}
+		hash := common.HexToHash(row.Hash)
+
+		receipt, err := client.TransactionReceipt(context.Background(), hash)
+		if err == nil {
+			row.Status, row.Error = batchSuccess, ""
+			if receipt.Status == types.ReceiptStatusFailed {
+				row.Status = batchReverted
+			}
+			continue
+		}
+		if _, isPending, err := client.TransactionByHash(context.Background(), hash); err == nil && isPending {
+			row.Status = batchSent
+			continue
+		}
+
+		// A consumed nonce means the transaction was replaced, sending again could pay twice
+		nonce, err := client.NonceAt(context.Background(), from, nil)
+		if err != nil {
+			return err
+		}
+		if row.Nonce != nil && nonce > *row.Nonce {
+			row.Status = batchReplaced
+			continue
+		}
+		row.Nonce, row.Hash, row.Status = nil, "", batchPending
+	}
+	return nil
+}
+
+// Estimate the gas of every row and return the most the batch can cost
+func estimateBatch(client *ethclient.Client, template *Trade, todo []*BatchRow) (*big.Int, error) {
+	price := template.GasPrice
+	if template.Dynamic {
+		price = template.GasFeeCap
+	}
+
+	total := new(big.Int)
+	for _, row := range todo {
+		trade := *template
+		to := common.HexToAddress(row.To)
+		trade.To = &to
+		trade.Amount = row.Wei
+
+		gas, err := estimateTxGas(client, &trade)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", row.Row, err)
+		}
+		row.gas = gas
+
+		value, _ := new(big.Int).SetString(row.Wei, 10)
+		total.Add(total, value)
+		total.Add(total, new(big.Int).Mul(price, new(big.Int).SetUint64(gas)))
+	}
+	return total, nil
+}
+
+// Send rows in nonce order, keeping at most --concurrency transactions waiting for a receipt.
+// The hash and nonce of a row are saved before it is sent, a send that errors may still have reached the node
+func sendBatch(client *ethclient.Client, template *Trade, todo, rows []*BatchRow, resultsPath string) error {
+	nonce, err := client.PendingNonceAt(context.Background(), template.FromAddress)
+	if err != nil {
+		for _, row := range todo {
+			row.Status, row.Error = batchFailed, err.Error()
+		}
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	inflight := make(chan struct{}, batchConcurrency)
+	stopped := false
+	var saveErr error
+	save := func() {
+		if err := saveResults(rows, resultsPath); err != nil && saveErr == nil {
+			saveErr = err
+		}
+	}
+
+	for _, row := range todo {
+		// A failed send leaves a nonce gap, so later rows would only get stuck,
+		// and without the results file a resumed run could pay twice
+		mu.Lock()
+		if saveErr != nil {
+			stopped = true
+		}
+		if stopped {
+			row.Status = batchSkipped
+		}
+		mu.Unlock()
+		if stopped {
+			continue
+		}
+		inflight <- struct{}{}
+
+		trade := *template
+		to := common.HexToAddress(row.To)
+		trade.To = &to
+		trade.Amount = row.Wei
+		trade.Nonce = nonce
+		trade.GasLimit = row.gas
+
+		signedTx, err := types.SignTx(buildTx(&trade), types.NewLondonSigner(trade.ChainId), trade.Key)
+		if err == nil {
+			mu.Lock()
+			sentNonce := nonce
+			row.Nonce, row.Hash = &sentNonce, signedTx.Hash().Hex()
+			save()
+			err = saveErr
+			mu.Unlock()
+		}
+		if err == nil {
+			err = client.SendTransaction(context.Background(), signedTx)
+		}
+
+		mu.Lock()
+		if err != nil {
+			row.Status, row.Error = batchFailed, err.Error()
+			stopped = true
+		} else {
+			row.Status = batchSent
+			fmt.Fprintf(output.Text, "  [%d] %s %s %s -> %s\n", row.Row, row.Amount, row.Unit, row.To, row.Hash)
+			nonce++
+		}
+		save()
+		mu.Unlock()
+
+		if err != nil || noWait {
+			<-inflight
+			continue
+		}
+
+		wg.Add(1)
+		go func(row *BatchRow, tx *types.Transaction) {
+			defer wg.Done()
+			defer func() { <-inflight }()
+
+			receipt, err := waitForReceipt(client, tx, template.FromAddress)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, errReplaced):
+				row.Status, row.Error = batchReplaced, err.Error()
+			case err != nil:
+				// Dropped or timed out, a resumed run looks the hash up again
+				row.Status, row.Error = batchFailed, err.Error()
+			case receipt.Status == types.ReceiptStatusFailed:
+				row.Status = batchReverted
+			default:
+				row.Status = batchSuccess
+			}
+			save()
+		}(row, signedTx)
+	}
+	wg.Wait()
+	if saveErr != nil {
+		return fmt.Errorf("results file %s: %v", resultsPath, saveErr)
+	}
+	return nil
+}
+
+// Write the results file atomically
+func saveResults(rows []*BatchRow, path string) error {
+	content, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Print what is about to be sent
+func printBatchSummary(rows, todo []*BatchRow, total, balance *big.Int) {
+	fmt.Fprintln(output.Text, "╔═════════[ 📋 Batch configuration successful ]═════════╗")
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "rows", len(rows))
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "done", len(rows)-len(todo))
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "to send", len(todo))
+	fmt.Fprintf(output.Text, "  %-8s: %v %s (amounts and maximum fees)\n", "total", utils.EthNumberConverter(total.String(), "wei")["ether"], config.NativeSymbol())
+	fmt.Fprintf(output.Text, "  %-8s: %v %s\n", "balance", utils.EthNumberConverter(balance.String(), "wei")["ether"], config.NativeSymbol())
+	fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════════════════╝")
+}
+
+// Save the results and count the outcome of every row
+func reportBatch(rows []*BatchRow, resultsPath string) error {
+	if err := saveResults(rows, resultsPath); err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Status]++
+		if row.Error != "" {
+			fmt.Fprintf(output.Text, "  [%d] %s: %s\n", row.Row, row.Status, row.Error)
+		}
+	}
+
+	fmt.Fprintln(output.Text, "<-- 📊 Batch results written to", resultsPath, "-->")
+	for _, status := range []string{batchSuccess, batchSent, batchReverted, batchReplaced, batchFailed, batchSkipped} {
+		if counts[status] > 0 {
+			fmt.Fprintf(output.Text, "  %-8s: %d\n", status, counts[status])
+		}
+	}
+
+	if counts[batchSuccess]+counts[batchSent] != len(rows) {
+		return errors.New("some rows were not paid, run the batch again to resume")
+	}
+	return nil
+}
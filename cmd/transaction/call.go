@@ -0,0 +1,206 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// CallCmd represents the transaction/call command
+var CallCmd = &cobra.Command{
+	Use:   "call [args...]",
+	Short: "Call a contract function with eth_call and decode the result",
+	Example: `
+trade call -t 0xToken -s "balanceOf(address)(uint256)" 0xOwner:Call a view function by signature
+trade call -t 0xToken --abi erc20.json -m balanceOf 0xOwner:Call a view function from an ABI file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/call called")
+		method, err := resolveMethod()
+		if err != nil {
+			return err
+		}
+		data, err := encodeCall(method, args)
+		if err != nil {
+			return err
+		}
+		to, err := contractAddress()
+		if err != nil {
+			return err
+		}
+
+		client, _, err := config.DialNetwork()
+		if err != nil {
+			return err
+		}
+
+		// Calls are made from the configured account when there is one
+		msg := ethereum.CallMsg{To: &to, Data: data}
+		if from, ok := signer.ConfiguredAddress(); ok {
+			msg.From = from
+		}
+		result, err := client.CallContract(context.Background(), msg, nil)
+		if err != nil {
+			if data := revertData(err); len(data) > 0 {
+				return errors.New("call reverted: " + decodeRevert(data))
+			}
+			return err
+		}
+
+		fmt.Fprintln(output.Text, "<-- 📞", method.Sig, "-->")
+		if len(method.Outputs) == 0 {
+			fmt.Fprintln(output.Text, "  raw:", hexutil.Encode(result))
+			return nil
+		}
+		values, err := method.Outputs.Unpack(result)
+		if err != nil {
+			return err
+		}
+		utils.PrintABIValues(method.Outputs, values)
+		return nil
+	},
+}
+
+// SendCmd represents the transaction/send command
+var SendCmd = &cobra.Command{
+	Use:   "send [args...]",
+	Short: "Send a transaction calling a contract function",
+	Example: `
+trade send -t 0xToken -s "transfer(address,uint256)" 0xTo 1000:Call a function by signature
+trade send -t 0xToken --abi erc20.json -m transfer 0xTo 1000:Call a function from an ABI file
+trade send -t 0xVault -s "deposit() payable" --value 1000:Send wei along with the call`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/send called")
+		method, err := resolveMethod()
+		if err != nil {
+			return err
+		}
+		if method.IsConstant() {
+			fmt.Fprintln(output.Text, "<-- ⚠️  ", method.Sig, "does not change state, use trade call to read it -->")
+		}
+		data, err := encodeCall(method, args)
+		if err != nil {
+			return err
+		}
+		to, err := contractAddress()
+		if err != nil {
+			return err
+		}
+
+		trade, err := readInConfig()
+		if err != nil {
+			return err
+		}
+		trade.To = &to
+		trade.Data = data
+
+		// Contract calls only carry value when asked to
+		trade.Amount = "0"
+		trade.AmountUnit = "wei"
+		if contractValue != "" {
+			if _, ok := new(big.Int).SetString(contractValue, 10); !ok {
+				return errors.New("Check the value entered:<" + contractValue + ">, it must be an integer in wei")
+			}
+			trade.Amount = contractValue
+		}
+
+		fmt.Fprintln(output.Text, "<-- 📞", method.Sig, "-->")
+		values, _ := method.Inputs.Unpack(data[4:])
+		utils.PrintABIValues(method.Inputs, values)
+
+		return processConfig(trade)
+	},
+}
+
+var contractTo string
+var contractSig string
+var contractABI string
+var contractMethod string
+var contractValue string
+
+func init() {
+	// Add flags
+	CallCmd.Flags().StringVarP(&contractTo, "to", "t", "", "contract address (default is the to key of the configuration file)")
+	CallCmd.Flags().StringVarP(&contractSig, "sig", "s", "", "function signature, such as balanceOf(address)(uint256)")
+	CallCmd.Flags().StringVar(&contractABI, "abi", "", "ABI JSON file or compiled artifact")
+	CallCmd.Flags().StringVarP(&contractMethod, "method", "m", "", "method name or signature in the ABI file")
+
+	SendCmd.Flags().StringVarP(&contractTo, "to", "t", "", "contract address (default is the to key of the configuration file)")
+	SendCmd.Flags().StringVarP(&contractSig, "sig", "s", "", "function signature, such as transfer(address,uint256)")
+	SendCmd.Flags().StringVar(&contractABI, "abi", "", "ABI JSON file or compiled artifact")
+	SendCmd.Flags().StringVarP(&contractMethod, "method", "m", "", "method name or signature in the ABI file")
+	SendCmd.Flags().StringVar(&contractValue, "value", "", "wei sent along with the call (default is 0)")
+}
+
+// Resolve the function from --sig or from --abi and --method
+func resolveMethod() (abi.Method, error) {
+	switch {
+	case contractSig != "":
+		return utils.ParseMethodSignature(contractSig)
+	case contractABI != "" && contractMethod != "":
+		contractAbi, err := utils.LoadABI(contractABI)
+		if err != nil {
+			return abi.Method{}, err
+		}
+		return utils.FindMethod(contractAbi, contractMethod)
+	default:
+		return abi.Method{}, errors.New("use --sig, or --abi with --method, to select a function")
+	}
+}
+
+// ABI-encode the selector and arguments of a function call
+func encodeCall(method abi.Method, args []string) ([]byte, error) {
+	values, err := utils.ParseArgValues(method.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, method.ID...), packed...), nil
+}
+
+// The contract address from --to or the to key of the configuration file
+func contractAddress() (common.Address, error) {
+	to := contractTo
+	if to == "" {
+		to = viper.GetString("to")
+	}
+	if to == "" {
+		return common.Address{}, errors.New("please enter a valid contract address with --to")
+	}
+	return utils.ParseAddress(to)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	output "txtoolbox/cmd/output"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/spf13/cobra"
+)
+
+// DeployCmd represents the transaction/deploy command
+var DeployCmd = &cobra.Command{
+	Use:   "deploy [constructor args...]",
+	Short: "Deploy a contract from bytecode or a Foundry/Hardhat artifact",
+	Example: `
+trade deploy -a out/Token.sol/Token.json "My Token" MTK 1000000:Deploy a Foundry artifact with constructor arguments
+trade deploy -a artifacts/contracts/Vault.sol/Vault.json --value 1000:Deploy a Hardhat artifact to a payable constructor
+trade deploy -b Token.bin -s "constructor(string,uint256)" Token 100:Deploy raw bytecode with a constructor signature`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/deploy called")
+		code, constructor, err := loadDeployCode()
+		if err != nil {
+			return err
+		}
+		if len(args) != len(constructor.Inputs) {
+			return fmt.Errorf("the constructor takes %d arguments, got %d", len(constructor.Inputs), len(args))
+		}
+		values, err := utils.ParseArgValues(constructor.Inputs, args)
+		if err != nil {
+			return err
+		}
+		packed, err := constructor.Inputs.Pack(values...)
+		if err != nil {
+			return err
+		}
+
+		trade, err := readInConfig()
+		if err != nil {
+			return err
+		}
+		// A contract creation has no recipient, the init code is the bytecode followed by the arguments
+		trade.To = nil
+		trade.Data = append(append([]byte{}, code...), packed...)
+
+		trade.Amount, trade.AmountUnit = "0", "wei"
+		if deployValue != "" {
+			if _, ok := new(big.Int).SetString(deployValue, 10); !ok {
+				return errors.New("Check the value entered:<" + deployValue + ">, it must be an integer in wei")
+			}
+			if !constructor.IsPayable() && deployValue != "0" {
+				return errors.New("the constructor is not payable, remove --value")
+			}
+			trade.Amount = deployValue
+		}
+
+		fmt.Fprintf(output.Text, "<-- 🏗️  Deploying %d bytes of bytecode -->\n", len(code))
+		if len(values) > 0 {
+			utils.PrintABIValues(constructor.Inputs, values)
+		}
+		return processConfig(trade)
+	},
+}
+
+var deployArtifact string
+var deployBytecode string
+var deploySig string
+var deployABI string
+var deployValue string
+
+func init() {
+	// Add flags
+	DeployCmd.Flags().StringVarP(&deployArtifact, "artifact", "a", "", "Foundry or Hardhat artifact JSON holding the ABI and bytecode")
+	DeployCmd.Flags().StringVarP(&deployBytecode, "bytecode", "b", "", "creation bytecode as hex or a file holding it")
+	DeployCmd.Flags().StringVarP(&deploySig, "sig", "s", "", "constructor signature for --bytecode, such as constructor(string,uint256)")
+	DeployCmd.Flags().StringVar(&deployABI, "abi", "", "ABI JSON file holding the constructor for --bytecode")
+	DeployCmd.Flags().StringVar(&deployValue, "value", "", "wei sent to a payable constructor (default is 0)")
+	DeployCmd.MarkFlagsMutuallyExclusive("artifact", "bytecode")
+	DeployCmd.MarkFlagsOneRequired("artifact", "bytecode")
+}
+
+// Load the creation bytecode and the constructor from --artifact or --bytecode
+func loadDeployCode() ([]byte, abi.Method, error) {
+	if deployArtifact != "" {
+		contractAbi, code, err := utils.LoadArtifact(deployArtifact)
+		if err != nil {
+			return nil, abi.Method{}, err
+		}
+		return code, contractAbi.Constructor, nil
+	}
+
+	code, err := utils.ReadBytecode(deployBytecode)
+	if err != nil {
+		return nil, abi.Method{}, err
+	}
+
+	switch {
+	case deploySig != "":
+		method, err := utils.ParseMethodSignature(deploySig)
+		if err != nil {
+			return nil, abi.Method{}, err
+		}
+		return code, abi.NewMethod("", "", abi.Constructor, method.StateMutability, false, method.Payable, method.Inputs, nil), nil
+	case deployABI != "":
+		contractAbi, err := utils.LoadABI(deployABI)
+		if err != nil {
+			return nil, abi.Method{}, err
+		}
+		return code, contractAbi.Constructor, nil
+	default:
+		// Without a signature the constructor takes no arguments
+		return code, abi.NewMethod("", "", abi.Constructor, "nonpayable", false, false, nil, nil), nil
+	}
+}
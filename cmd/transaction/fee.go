@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Number of recent blocks sampled by eth_feeHistory
+const feeHistoryBlocks = 20
+
+// Reward percentile used as the priority fee of each sampled block
+const feeHistoryPercentile = 50
+
+// Check whether the chain supports EIP-1559 and return the base fee of the next block
+func nextBaseFee(client *ethclient.Client) (*big.Int, error) {
+	header, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Chains without a base fee only accept legacy transactions
+	if header.BaseFee == nil {
+		return nil, nil
+	}
+
+	history, err := client.FeeHistory(context.Background(), 1, nil, nil)
+	if err != nil || len(history.BaseFee) == 0 {
+		return header.BaseFee, nil
+	}
+	// The last base fee returned by eth_feeHistory belongs to the pending block
+	return history.BaseFee[len(history.BaseFee)-1], nil
+}
+
+// Suggest maxPriorityFeePerGas and maxFeePerGas from eth_feeHistory
+func suggestDynamicFees(client *ethclient.Client, baseFee *big.Int) (*big.Int, *big.Int, error) {
+	if baseFee == nil {
+		return nil, nil, errors.New("the chain has no base fee")
+	}
+
+	history, err := client.FeeHistory(context.Background(), feeHistoryBlocks, nil, []float64{feeHistoryPercentile})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Take the median of the per-block priority fees
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil && reward[0].Sign() > 0 {
+			rewards = append(rewards, reward[0])
+		}
+	}
+
+	var gasTipCap *big.Int
+	if len(rewards) == 0 {
+		// Empty blocks report no rewards, so ask the node directly
+		gasTipCap, err = client.SuggestGasTipCap(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		sort.Slice(rewards, func(i, j int) bool {
+			return rewards[i].Cmp(rewards[j]) < 0
+		})
+		gasTipCap = new(big.Int).Set(rewards[len(rewards)/2])
+	}
+
+	// Leave room for the base fee to double before the transaction is priced out
+	gasFeeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+	gasFeeCap.Add(gasFeeCap, gasTipCap)
+
+	return gasTipCap, gasFeeCap, nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SignCmd represents the transaction/sign command
+var SignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a fully specified transaction without network access",
+	Example: `
+trade sign:Print the raw signed transaction
+trade sign --envelope --out tx.json:Write a JSON envelope to a file
+trade sign --chain-id 1:Sign for a chain not set in the configuration file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/sign called")
+		trade, err := readInConfig()
+		if err != nil {
+			return err
+		}
+		if err := prepareOffline(trade); err != nil {
+			return err
+		}
+
+		signedTx, err := types.SignTx(buildTx(trade), types.NewLondonSigner(trade.ChainId), trade.Key)
+		if err != nil {
+			return errors.New("signature transaction failed")
+		}
+		fmt.Fprintln(output.Text, "<-- 📝 Tx hash configuration successful:", signedTx.Hash().Hex(), "-->")
+
+		return writeSignedTx(signedTx, trade.FromAddress)
+	},
+}
+
+// BroadcastCmd represents the transaction/broadcast command
+var BroadcastCmd = &cobra.Command{
+	Use:   "broadcast [raw]",
+	Short: "Broadcast a signed transaction from an argument, a file or stdin",
+	Example: `
+trade broadcast 0x02f8..:Broadcast a raw transaction
+trade broadcast -f tx.json:Broadcast a raw transaction or JSON envelope from a file
+cat tx.hex | trade broadcast -y:Broadcast from stdin, --yes is needed as stdin is taken`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/broadcast called")
+		input, err := utils.ReadRawInput(args, broadcastFile)
+		if err != nil {
+			return err
+		}
+		tx, err := utils.DecodeRawTx(input)
+		if err != nil {
+			return err
+		}
+
+		client, chainID, err := config.DialNetwork()
+		if err != nil {
+			return err
+		}
+		if tx.Protected() && tx.ChainId().Cmp(chainID) != 0 {
+			return fmt.Errorf("the transaction is signed for chainID %v, but the network is chainID %v", tx.ChainId(), chainID)
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return err
+		}
+		printTxSummary(tx, from)
+
+		send, err := prompt.Confirm("Send transaction?")
+		if err != nil {
+			return err
+		}
+		if !send {
+			os.Exit(0)
+		}
+
+		err = client.SendTransaction(context.Background(), tx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(output.Text, "<-- 🚀 Transaction sent-->")
+		return waitAndReport(client, tx, from)
+	},
+}
+
+// JSON envelope written by trade sign --envelope
+type SignedEnvelope struct {
+	Raw     string `json:"raw"`
+	Hash    string `json:"hash"`
+	Type    uint8  `json:"type"`
+	ChainID string `json:"chainId"`
+	From    string `json:"from"`
+	Nonce   uint64 `json:"nonce"`
+	To      string `json:"to,omitempty"`
+	Value   string `json:"value"`
+}
+
+var signChainID string
+var signEnvelope bool
+var signOut string
+var broadcastFile string
+
+func init() {
+	// Add flags
+	SignCmd.Flags().StringVar(&signChainID, "chain-id", "", "chain ID to sign for (default is the chainId key of the configuration file)")
+	SignCmd.Flags().BoolVar(&signEnvelope, "envelope", false, "write a JSON envelope instead of the raw hex")
+	SignCmd.Flags().StringVar(&signOut, "out", "", "file to write the signed transaction to (default is stdout)")
+
+	BroadcastCmd.Flags().StringVarP(&broadcastFile, "file", "f", "", "file holding the raw transaction or JSON envelope, - for stdin")
+
+	// Without --out the signed transaction is the only thing on stdout, so it can be piped.
+	// This runs before the configuration file is read, which already prints.
+	cobra.OnInitialize(func() {
+		if SignCmd.CalledAs() != "" && signOut == "" {
+			output.TextToStderr()
+		}
+	})
+}
+
+// Check that every field is given, since nothing can be looked up offline
+func prepareOffline(trade *Trade) error {
+	chainID := signChainID
+	if chainID == "" {
+		chainID = config.GetString("chainId")
+	}
+	var ok bool
+	trade.ChainId, ok = new(big.Int).SetString(chainID, 10)
+	if !ok {
+		return errors.New("chainId is required to sign offline, set it with --chain-id or the chainId key")
+	}
+
+	if !viper.IsSet("nonce") {
+		return errors.New("nonce is required to sign offline")
+	}
+	if trade.GasLimit == 0 {
+		return errors.New("gaslimit is required to sign offline")
+	}
+	if trade.To == nil || *trade.To == (common.Address{}) {
+		return errors.New("to address is empty")
+	}
+
+	// Fees decide the transaction type
+	switch {
+	case trade.GasFeeCap != nil || trade.GasTipCap != nil:
+		if trade.GasFeeCap == nil || trade.GasTipCap == nil {
+			return errors.New("both maxFeePerGas and maxPriorityFeePerGas are required to sign offline")
+		}
+		if trade.GasTipCap.Cmp(trade.GasFeeCap) > 0 {
+			return errors.New("maxPriorityFeePerGas cannot be greater than maxFeePerGas")
+		}
+		trade.Dynamic = true
+		trade.GasPrice = nil
+	case trade.GasPrice == nil:
+		return errors.New("maxFeePerGas and maxPriorityFeePerGas, or gasprice, are required to sign offline")
+	}
+
+	value, err := utils.ToWei(trade.Amount, trade.AmountUnit)
+	if err != nil {
+		return err
+	}
+	trade.Amount, trade.AmountUnit = value.String(), "wei"
+
+	privateKey, err := signer.LoadKey()
+	if err != nil {
+		return err
+	}
+	trade.Key = privateKey
+	trade.FromAddress = crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	printTxSummary(buildTx(trade), trade.FromAddress)
+	return nil
+}
+
+// Write the signed transaction as raw hex or as a JSON envelope
+func writeSignedTx(signedTx *types.Transaction, from common.Address) error {
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	envelope := SignedEnvelope{
+		Raw:     hexutil.Encode(raw),
+		Hash:    signedTx.Hash().Hex(),
+		Type:    signedTx.Type(),
+		ChainID: signedTx.ChainId().String(),
+		From:    from.Hex(),
+		Nonce:   signedTx.Nonce(),
+		Value:   signedTx.Value().String(),
+	}
+	if signedTx.To() != nil {
+		envelope.To = signedTx.To().Hex()
+	}
+
+	// Structured output always holds the envelope, it carries the raw transaction
+	if signOut == "" && output.Structured() {
+		return output.Print(envelope)
+	}
+	content := envelope.Raw
+	if signEnvelope {
+		encoded, err := json.MarshalIndent(envelope, "", "  ")
+		if err != nil {
+			return err
+		}
+		content = string(encoded)
+	}
+
+	if signOut == "" {
+		return output.Write(content)
+	}
+	if err := os.WriteFile(signOut, []byte(content+"\n"), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintln(output.Text, "<-- 💾 Signed transaction written to", signOut, "-->")
+	return nil
+}
+
+// Print the fields of a transaction before it is signed or sent
+func printTxSummary(tx *types.Transaction, from common.Address) {
+	gwei := func(wei *big.Int) string {
+		return utils.EthNumberConverter(wei.String(), "wei")["gwei"]
+	}
+
+	fmt.Fprintln(output.Text, "╔══════════[ 📦 Transaction summary ]══════════╗")
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "type", tx.Type())
+	fmt.Fprintf(output.Text, "  %-8s: %v\n", "chainId", tx.ChainId())
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "from", from.Hex())
+	if tx.To() != nil {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "to", tx.To().Hex())
+	} else {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "to", "contract creation")
+	}
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "nonce", tx.Nonce())
+	fmt.Fprintf(output.Text, "  %-8s: %v %s\n", "value", utils.EthNumberConverter(tx.Value().String(), "wei")["ether"], config.NativeSymbol())
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "gas", tx.Gas())
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "gasPrice", gwei(tx.GasPrice()))
+	} else {
+		fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "maxFee", gwei(tx.GasFeeCap()))
+		fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "tip", gwei(tx.GasTipCap()))
+	}
+	if len(tx.Data()) > 0 {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "data", hexutil.Encode(tx.Data()))
+	}
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+}
@@ -0,0 +1,275 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/spf13/cobra"
+)
+
+// PermitCmd represents the transaction/permit command
+var PermitCmd = &cobra.Command{
+	Use:   "permit",
+	Short: "Sign an ERC-2612 permit and print v/r/s and the permit calldata",
+	Example: `
+trade permit --token 0xA0b8..eB48 --spender 0x.. --amount 100:Allow the spender 100 tokens for one hour
+trade permit --token 0xA0b8..eB48 --spender 0x.. --unlimited --deadline 24h:Allow an unlimited amount for a day
+trade permit --token 0x.. --spender 0x.. --amount 1 --deadline 1893456000 --output json:Deadline as a unix timestamp`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/permit called")
+		token, err := utils.ParseAddress(permitToken)
+		if err != nil {
+			return err
+		}
+		spender, err := utils.ParseAddress(permitSpender)
+		if err != nil {
+			return err
+		}
+		if permitAmount == "" && !permitUnlimited {
+			return errors.New("please enter an --amount or use --unlimited")
+		}
+		deadline, err := parseDeadline(permitDeadline)
+		if err != nil {
+			return err
+		}
+
+		client, chainID, err := config.DialNetwork()
+		if err != nil {
+			return err
+		}
+		privateKey, err := signer.LoadKey()
+		if err != nil {
+			return err
+		}
+		owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+		info, err := utils.ReadToken(client, token, owner)
+		if err != nil {
+			return err
+		}
+		value := math.MaxBig256
+		amount := "unlimited"
+		if !permitUnlimited {
+			if value, err = utils.ParseUnits(permitAmount, info.Decimals); err != nil {
+				return err
+			}
+			amount = permitAmount
+		}
+		printTokenTransfer(info, "spender", spender, amount)
+		if err := checkRecipients(spender); err != nil {
+			return err
+		}
+
+		typedData, err := permitTypedData(client, chainID, token, owner, spender, value, deadline)
+		if err != nil {
+			return err
+		}
+		hash, _, err := utils.HashTypedData(typedData)
+		if err != nil {
+			return err
+		}
+		utils.PrintTypedData(typedData, owner)
+
+		sign, err := prompt.Confirm("Sign the permit?")
+		if err != nil {
+			return err
+		}
+		if !sign {
+			os.Exit(0)
+		}
+		signature, err := utils.SignHash(hash, privateKey)
+		if err != nil {
+			return err
+		}
+
+		r, s := common.HexToHash(signature.R), common.HexToHash(signature.S)
+		data, err := utils.PackCall(utils.ERC2612Permit, owner, spender, value, deadline, signature.V, r, s)
+		if err != nil {
+			return err
+		}
+		result := PermitResult{
+			Token:     token.Hex(),
+			Owner:     owner.Hex(),
+			Spender:   spender.Hex(),
+			Value:     value.String(),
+			Nonce:     typedData.Message["nonce"].(string),
+			Deadline:  deadline.String(),
+			V:         signature.V,
+			R:         signature.R,
+			S:         signature.S,
+			Signature: signature.Signature,
+			Calldata:  hexutil.Encode(data),
+		}
+
+		if output.Structured() {
+			return output.Print(result)
+		}
+		fmt.Fprintln(output.Text, "╔══════════════[ ✍️  Permit signature ]══════════════╗")
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "nonce", result.Nonce)
+		fmt.Fprintf(output.Text, "  %-8s: %s (%s)\n", "deadline", result.Deadline, time.Unix(deadline.Int64(), 0).UTC().Format(time.RFC3339))
+		fmt.Fprintf(output.Text, "  %-8s: %d\n", "v", result.V)
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "r", result.R)
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "s", result.S)
+		fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════════════╝")
+		fmt.Fprintln(output.Text, "<-- 📝 permit calldata, send it to", token.Hex(), "-->")
+		fmt.Fprintln(output.Text, result.Calldata)
+		return nil
+	},
+}
+
+// PermitResult is the structured output of permit, the value is in the smallest token unit
+type PermitResult struct {
+	Token     string `json:"token"`
+	Owner     string `json:"owner"`
+	Spender   string `json:"spender"`
+	Value     string `json:"value"`
+	Nonce     string `json:"nonce"`
+	Deadline  string `json:"deadline"`
+	V         uint8  `json:"v"`
+	R         string `json:"r"`
+	S         string `json:"s"`
+	Signature string `json:"signature"`
+	Calldata  string `json:"calldata"`
+}
+
+// EIP-712 types of an ERC-2612 permit
+var permitTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"Permit": {
+		{Name: "owner", Type: "address"},
+		{Name: "spender", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	},
+}
+
+var permitToken string
+var permitSpender string
+var permitAmount string
+var permitUnlimited bool
+var permitDeadline string
+var permitVersion string
+
+func init() {
+	// Add flags
+	PermitCmd.Flags().StringVar(&permitToken, "token", "", "ERC-20 token contract implementing ERC-2612")
+	PermitCmd.Flags().StringVar(&permitSpender, "spender", "", "address allowed to spend the tokens")
+	PermitCmd.Flags().StringVarP(&permitAmount, "amount", "a", "", "amount in whole tokens, such as 12.5")
+	PermitCmd.Flags().BoolVar(&permitUnlimited, "unlimited", false, "allow the maximum uint256 amount")
+	PermitCmd.Flags().StringVar(&permitDeadline, "deadline", "1h", "deadline as a duration from now, such as 30m, or a unix timestamp")
+	PermitCmd.Flags().StringVar(&permitVersion, "version", "", "EIP-712 domain version (default is read from the token)")
+	PermitCmd.MarkFlagRequired("token")
+	PermitCmd.MarkFlagRequired("spender")
+	PermitCmd.MarkFlagsMutuallyExclusive("amount", "unlimited")
+}
+
+// Build the permit typed data, with the domain version that matches the token's DOMAIN_SEPARATOR
+func permitTypedData(client *ethclient.Client, chainID *big.Int, token, owner, spender common.Address, value, deadline *big.Int) (apitypes.TypedData, error) {
+	name, err := utils.CallMethod(client, token, utils.ERC20Name)
+	if err != nil {
+		return apitypes.TypedData{}, fmt.Errorf("name(): %v", err)
+	}
+	nonce, err := utils.CallMethod(client, token, utils.ERC2612Nonces, owner)
+	if err != nil {
+		return apitypes.TypedData{}, fmt.Errorf("nonces(): %v, the token may not support ERC-2612", err)
+	}
+	separator, err := utils.CallMethod(client, token, utils.ERC2612DomainSeparator)
+	if err != nil {
+		return apitypes.TypedData{}, fmt.Errorf("DOMAIN_SEPARATOR(): %v, the token may not support ERC-2612", err)
+	}
+
+	typedData := apitypes.TypedData{
+		Types:       permitTypes,
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              name[0].(string),
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  spender.Hex(),
+			"value":    value.String(),
+			"nonce":    nonce[0].(*big.Int).String(),
+			"deadline": deadline.String(),
+		},
+	}
+
+	// Most tokens have no version(), and use "1" or "2"
+	versions := []string{permitVersion}
+	if permitVersion == "" {
+		versions = []string{"1", "2"}
+		if version, err := utils.CallMethod(client, token, utils.EIP712Version); err == nil {
+			versions = append([]string{version[0].(string)}, versions...)
+		}
+	}
+	expected := separator[0].([32]byte)
+	for _, version := range versions {
+		typedData.Domain.Version = version
+		domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+		if err != nil {
+			return apitypes.TypedData{}, err
+		}
+		if common.BytesToHash(domainSeparator) == expected {
+			return typedData, nil
+		}
+	}
+	return apitypes.TypedData{}, fmt.Errorf("no domain version matches DOMAIN_SEPARATOR %s, set it with --version", common.Hash(expected).Hex())
+}
+
+// A deadline given as a duration from now or as a unix timestamp
+func parseDeadline(input string) (*big.Int, error) {
+	if duration, err := time.ParseDuration(input); err == nil && duration > 0 {
+		return big.NewInt(time.Now().Add(duration).Unix()), nil
+	}
+	timestamp, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || timestamp <= 0 {
+		return nil, errors.New("Check the deadline entered:<" + input + ">, use a duration such as 30m or a unix timestamp")
+	}
+	if timestamp < time.Now().Unix() {
+		return nil, errors.New("the deadline has already passed:<" + input + ">")
+	}
+	return big.NewInt(timestamp), nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Interval between receipt checks when the node cannot push new heads
+const receiptPollInterval = 2 * time.Second
+
+// Consecutive checks without the transaction before it is reported as dropped
+const droppedAfterMisses = 3
+
+// Returned by waitForReceipt when another transaction used the nonce
+var errReplaced = errors.New("replaced by another transaction")
+
+var noWait bool
+var confirmations uint64
+var receiptTimeout time.Duration
+
+func init() {
+	// Add flags
+	TransactionCmd.PersistentFlags().BoolVar(&noWait, "no-wait", false, "exit after sending without waiting for the receipt")
+	TransactionCmd.PersistentFlags().Uint64Var(&confirmations, "confirmations", 1, "number of blocks to wait for, including the one holding the transaction")
+	TransactionCmd.PersistentFlags().DurationVar(&receiptTimeout, "timeout", 5*time.Minute, "how long to wait for the receipt")
+}
+
+// Wait for the receipt and report the outcome of a sent transaction
+func waitAndReport(client *ethclient.Client, tx *types.Transaction, from common.Address) error {
+	// Link the transaction on the explorer of the active profile
+	if link := explorerLink(tx); link != "" {
+		fmt.Fprintln(output.Text, "<-- 🔗", link, "-->")
+	}
+	if noWait {
+		if output.Structured() {
+			output.Add("result", TradeResult{Hash: tx.Hash().Hex(), Status: "sent", Explorer: explorerLink(tx)})
+		}
+		return nil
+	}
+
+	fmt.Fprintln(output.Text, "<-- ⏳ Waiting for", confirmations, "confirmation(s), timeout", receiptTimeout, "-->")
+	receipt, err := waitForReceipt(client, tx, from)
+	if err != nil {
+		return err
+	}
+	if output.Structured() {
+		output.Add("result", receiptResult(client, tx, from, receipt))
+	} else {
+		printReceipt(client, tx, from, receipt)
+	}
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		return errors.New("transaction reverted")
+	}
+	return nil
+}
+
+// Link to the transaction on the explorer of the active profile
+func explorerLink(tx *types.Transaction) string {
+	explorer := config.GetString("explorer")
+	if explorer == "" {
+		return ""
+	}
+	return explorer + "/tx/" + tx.Hash().Hex()
+}
+
+// Wait until the transaction is mined with enough confirmations, replaced or timed out
+func waitForReceipt(client *ethclient.Client, tx *types.Transaction, from common.Address) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+	defer cancel()
+
+	// Prefer new head notifications over ws, and poll over http
+	heads := make(chan *types.Header, 16)
+	subscribed := false
+	if sub, err := client.SubscribeNewHead(ctx, heads); err == nil {
+		defer sub.Unsubscribe()
+		subscribed = true
+	}
+
+	missing := 0
+	for {
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		switch {
+		case err == nil:
+			confirmed, err := isConfirmed(ctx, client, receipt)
+			if err != nil {
+				return nil, err
+			}
+			if confirmed {
+				return receipt, nil
+			}
+		case errors.Is(err, ethereum.NotFound):
+			// A higher account nonce without a receipt means another transaction took the slot
+			nonce, err := client.NonceAt(ctx, from, nil)
+			if err == nil && nonce > tx.Nonce() {
+				// The transaction itself may have been mined since the receipt was requested
+				if _, err := client.TransactionReceipt(ctx, tx.Hash()); err == nil {
+					continue
+				}
+				return nil, fmt.Errorf("transaction %s was %w with nonce %d", tx.Hash().Hex(), errReplaced, tx.Nonce())
+			}
+			// Load balanced nodes may not see the transaction right away
+			if _, _, err := client.TransactionByHash(ctx, tx.Hash()); errors.Is(err, ethereum.NotFound) {
+				missing++
+			} else {
+				missing = 0
+			}
+			if missing >= droppedAfterMisses {
+				return nil, fmt.Errorf("transaction %s was dropped by the node", tx.Hash().Hex())
+			}
+		default:
+			if ctx.Err() == nil {
+				return nil, err
+			}
+		}
+
+		var poll <-chan time.Time
+		if !subscribed {
+			poll = time.After(receiptPollInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no receipt for %s after %s, it may still be pending", tx.Hash().Hex(), receiptTimeout)
+		case <-heads:
+		case <-poll:
+		}
+	}
+}
+
+// Check that the receipt is deep enough and still part of the canonical chain
+func isConfirmed(ctx context.Context, client *ethclient.Client, receipt *types.Receipt) (bool, error) {
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	depth := new(big.Int).Sub(new(big.Int).SetUint64(head), receipt.BlockNumber)
+	if depth.Sign() < 0 || depth.Uint64()+1 < confirmations {
+		return false, nil
+	}
+
+	header, err := client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return false, err
+	}
+	// The block was reorged out, wait for the transaction to be included again
+	return header.Hash() == receipt.BlockHash, nil
+}
+
+// Print the receipt, the emitted logs and the revert reason of failed transactions
+func printReceipt(client *ethclient.Client, tx *types.Transaction, from common.Address, receipt *types.Receipt) {
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = tx.GasPrice()
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+
+	status := "✅ success"
+	if receipt.Status == types.ReceiptStatusFailed {
+		status = "❌ reverted"
+	}
+
+	fmt.Fprintln(output.Text, "╔══════════[ 🧾 Transaction receipt ]══════════╗")
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "status", status)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "hash", receipt.TxHash.Hex())
+	fmt.Fprintf(output.Text, "  %-9s: %v\n", "block", receipt.BlockNumber)
+	fmt.Fprintf(output.Text, "  %-9s: %d / %d\n", "gasUsed", receipt.GasUsed, tx.Gas())
+	fmt.Fprintf(output.Text, "  %-9s: %v gwei\n", "gasPrice", utils.EthNumberConverter(gasPrice.String(), "wei")["gwei"])
+	fmt.Fprintf(output.Text, "  %-9s: %v %s\n", "fee", utils.EthNumberConverter(fee.String(), "wei")["ether"], config.NativeSymbol())
+	codeSize := -1
+	if receipt.ContractAddress != (common.Address{}) {
+		codeSize = deployedCodeSize(client, receipt)
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "contract", receipt.ContractAddress.Hex())
+		fmt.Fprintf(output.Text, "  %-9s: %d bytes\n", "codeSize", codeSize)
+	}
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+	if codeSize == 0 && receipt.Status == types.ReceiptStatusSuccessful {
+		fmt.Fprintln(output.Text, "<-- ⚠️  The constructor returned no code, the contract address is empty -->")
+	}
+
+	for _, log := range receipt.Logs {
+		fmt.Fprintf(output.Text, "<-- 📜 Log %d: %s -->\n", log.Index, log.Address.Hex())
+		for i, topic := range log.Topics {
+			fmt.Fprintf(output.Text, "  topic%d: %s\n", i, topic.Hex())
+		}
+		if len(log.Data) > 0 {
+			fmt.Fprintf(output.Text, "  data  : %s\n", hexutil.Encode(log.Data))
+		}
+	}
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		fmt.Fprintln(output.Text, "<-- 💥 Revert reason:", replayRevertReason(client, tx, from, receipt.BlockNumber), "-->")
+	}
+}
+
+// Size of the runtime code at the created contract address, as of the receipt block
+func deployedCodeSize(client *ethclient.Client, receipt *types.Receipt) int {
+	code, err := client.CodeAt(context.Background(), receipt.ContractAddress, receipt.BlockNumber)
+	if err != nil {
+		return 0
+	}
+	return len(code)
+}
+
+// Replay a failed transaction with eth_call to recover its revert reason.
+// The replay runs on the state before the block that included it, the state after
+// already holds the effects of the block and may not revert at all
+func replayRevertReason(client *ethclient.Client, tx *types.Transaction, from common.Address, blockNumber *big.Int) string {
+	parent := new(big.Int).Sub(blockNumber, big.NewInt(1))
+	if parent.Sign() < 0 {
+		parent.SetInt64(0)
+	}
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	_, err := client.CallContract(context.Background(), msg, parent)
+	if err == nil {
+		// The call succeeds in isolation, most likely the transaction ran out of gas
+		return "unknown, the replay succeeded (out of gas?)"
+	}
+	return strings.TrimSpace(revertReason(err))
+}
@@ -0,0 +1,251 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+// Minimum fee bump accepted by the node to replace a pending transaction
+const minReplaceBump = 10
+
+// SpeedupCmd represents the transaction/speedup command
+var SpeedupCmd = &cobra.Command{
+	Use:   "speedup",
+	Short: "Re-send a pending transaction with higher fees",
+	Example: `
+trade speedup --hash 0x..:Bump the fees of a pending transaction by 10%
+trade speedup --hash 0x.. --bump 30:Bump the fees by 30%`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/speedup called")
+		return replaceTx(false)
+	},
+}
+
+// CancelCmd represents the transaction/cancel command
+var CancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Replace a pending transaction with a 0-value transfer to yourself",
+	Example: `
+trade cancel --hash 0x..:Cancel a pending transaction`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/cancel called")
+		return replaceTx(true)
+	},
+}
+
+var replaceHash string
+var replaceBump uint64
+
+func init() {
+	// Add flags
+	SpeedupCmd.Flags().StringVar(&replaceHash, "hash", "", "hash of the pending transaction")
+	SpeedupCmd.Flags().Uint64Var(&replaceBump, "bump", minReplaceBump, "fee increase in percent, at least 10")
+	SpeedupCmd.MarkFlagRequired("hash")
+
+	CancelCmd.Flags().StringVar(&replaceHash, "hash", "", "hash of the pending transaction")
+	CancelCmd.Flags().Uint64Var(&replaceBump, "bump", minReplaceBump, "fee increase in percent, at least 10")
+	CancelCmd.MarkFlagRequired("hash")
+}
+
+// Replace a pending transaction at the same nonce, speeding it up or cancelling it
+func replaceTx(cancel bool) error {
+	if replaceBump < minReplaceBump {
+		return fmt.Errorf("the bump must be at least %d%%", minReplaceBump)
+	}
+	hash := common.HexToHash(replaceHash)
+	if len(replaceHash) != 66 {
+		return errors.New("please enter a valid transaction hash")
+	}
+
+	client, chainID, err := config.DialNetwork()
+	if err != nil {
+		return err
+	}
+
+	// Check the pending transaction
+	pendingTx, isPending, err := client.TransactionByHash(context.Background(), hash)
+	if err != nil {
+		return err
+	}
+	if !isPending {
+		return errors.New("the transaction is already mined and cannot be replaced")
+	}
+	if pendingTx.Type() == types.BlobTxType {
+		return errors.New("blob transactions cannot be replaced by txtoolbox")
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), pendingTx)
+	if err != nil {
+		return err
+	}
+	privateKey, err := signer.LoadKey()
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(privateKey.PublicKey) != from {
+		return fmt.Errorf("the transaction was sent by %s, not by the configured signer", from.Hex())
+	}
+
+	replacement, err := buildReplacement(client, pendingTx, from, cancel)
+	if err != nil {
+		return err
+	}
+	printReplacement(pendingTx, replacement)
+
+	signedTx, err := types.SignTx(replacement, types.NewLondonSigner(chainID), privateKey)
+	if err != nil {
+		return errors.New("signature transaction failed")
+	}
+	fmt.Fprintln(output.Text, "<-- 📝 Tx hash configuration successful:", signedTx.Hash().Hex(), "-->")
+
+	send, err := prompt.Confirm("Send replacement transaction?")
+	if err != nil {
+		return err
+	}
+	if !send {
+		os.Exit(0)
+	}
+
+	err = client.SendTransaction(context.Background(), signedTx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output.Text, "<-- 🚀 Replacement transaction sent-->")
+	return waitAndReport(client, signedTx, from)
+}
+
+// Build a transaction with the same nonce and fees bumped past the replacement rule
+func buildReplacement(client *ethclient.Client, pendingTx *types.Transaction, from common.Address, cancel bool) (*types.Transaction, error) {
+	to, value, gas, data := pendingTx.To(), pendingTx.Value(), pendingTx.Gas(), pendingTx.Data()
+	if cancel {
+		to, value, gas, data = &from, new(big.Int), 21000, nil
+	}
+
+	switch pendingTx.Type() {
+	case types.DynamicFeeTxType:
+		baseFee, err := nextBaseFee(client)
+		if err != nil {
+			return nil, err
+		}
+		gasTipCap, gasFeeCap, err := suggestDynamicFees(client, baseFee)
+		if err != nil {
+			return nil, err
+		}
+		// Both fields must be bumped, and never priced below the current market
+		gasTipCap = maxBig(bumpFee(pendingTx.GasTipCap(), replaceBump), gasTipCap)
+		gasFeeCap = maxBig(bumpFee(pendingTx.GasFeeCap(), replaceBump), gasFeeCap)
+		gasFeeCap = maxBig(gasFeeCap, gasTipCap)
+
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    pendingTx.ChainId(),
+			Nonce:      pendingTx.Nonce(),
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: pendingTx.AccessList(),
+		}), nil
+
+	case types.LegacyTxType, types.AccessListTxType:
+		gasPrice, err := client.SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		gasPrice = maxBig(bumpFee(pendingTx.GasPrice(), replaceBump), gasPrice)
+
+		if pendingTx.Type() == types.AccessListTxType {
+			return types.NewTx(&types.AccessListTx{
+				ChainID:    pendingTx.ChainId(),
+				Nonce:      pendingTx.Nonce(),
+				GasPrice:   gasPrice,
+				Gas:        gas,
+				To:         to,
+				Value:      value,
+				Data:       data,
+				AccessList: pendingTx.AccessList(),
+			}), nil
+		}
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    pendingTx.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("transaction type %d cannot be replaced by txtoolbox", pendingTx.Type())
+	}
+}
+
+// Raise a fee by percent, rounding up so the node's minimum bump is always met
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// Print the old and new fees side by side
+func printReplacement(pendingTx, replacement *types.Transaction) {
+	gwei := func(wei *big.Int) string {
+		return utils.EthNumberConverter(wei.String(), "wei")["gwei"]
+	}
+
+	fmt.Fprintln(output.Text, "╔══════[ 🔁 Replacement configuration ]══════╗")
+	fmt.Fprintf(output.Text, "  %-8s: %d\n", "nonce", replacement.Nonce())
+	if replacement.To() != nil {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "to", replacement.To().Hex())
+	}
+	if replacement.Type() == types.DynamicFeeTxType {
+		fmt.Fprintf(output.Text, "  %-8s: %s -> %s gwei\n", "maxFee", gwei(pendingTx.GasFeeCap()), gwei(replacement.GasFeeCap()))
+		fmt.Fprintf(output.Text, "  %-8s: %s -> %s gwei\n", "tip", gwei(pendingTx.GasTipCap()), gwei(replacement.GasTipCap()))
+	} else {
+		fmt.Fprintf(output.Text, "  %-8s: %s -> %s gwei\n", "gasPrice", gwei(pendingTx.GasPrice()), gwei(replacement.GasPrice()))
+	}
+	fmt.Fprintln(output.Text, "╚════════════════════════════════════════════╝")
+}
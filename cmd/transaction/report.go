@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TradeSummary is the structured output of a transaction before it is signed, amounts are in wei
+type TradeSummary struct {
+	Network              string `json:"network"`
+	ChainID              string `json:"chainId"`
+	Type                 string `json:"type"`
+	From                 string `json:"from"`
+	To                   string `json:"to,omitempty"`
+	Contract             string `json:"contract,omitempty"`
+	Value                string `json:"value"`
+	Nonce                uint64 `json:"nonce"`
+	GasLimit             uint64 `json:"gasLimit"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	Data                 string `json:"data"`
+}
+
+// TradeResult is the structured output of a sent transaction, amounts are in wei
+type TradeResult struct {
+	Hash              string     `json:"hash"`
+	Status            string     `json:"status"`
+	Explorer          string     `json:"explorer,omitempty"`
+	Block             uint64     `json:"block,omitempty"`
+	GasUsed           uint64     `json:"gasUsed,omitempty"`
+	EffectiveGasPrice string     `json:"effectiveGasPrice,omitempty"`
+	Fee               string     `json:"fee,omitempty"`
+	ContractAddress   string     `json:"contractAddress,omitempty"`
+	CodeSize          *int       `json:"codeSize,omitempty"`
+	Logs              []TradeLog `json:"logs,omitempty"`
+	RevertReason      string     `json:"revertReason,omitempty"`
+}
+
+// TradeLog is a log emitted by a sent transaction
+type TradeLog struct {
+	Index   uint     `json:"index"`
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// Summarise the trade once every field is checked
+func tradeSummary(trade *Trade) TradeSummary {
+	summary := TradeSummary{
+		Network:  trade.NetWork,
+		ChainID:  trade.ChainId.String(),
+		Type:     "legacy",
+		From:     trade.FromAddress.Hex(),
+		Value:    trade.Amount,
+		Nonce:    trade.Nonce,
+		GasLimit: trade.GasLimit,
+		Data:     hexutil.Encode(trade.Data),
+	}
+	// Contract creations report the address predicted from the nonce
+	if trade.To == nil {
+		summary.Contract = crypto.CreateAddress(trade.FromAddress, trade.Nonce).Hex()
+	} else {
+		summary.To = trade.To.Hex()
+	}
+	if trade.Dynamic {
+		summary.Type = "eip1559"
+		summary.MaxFeePerGas = trade.GasFeeCap.String()
+		summary.MaxPriorityFeePerGas = trade.GasTipCap.String()
+	} else {
+		summary.GasPrice = trade.GasPrice.String()
+	}
+	return summary
+}
+
+// The result of a mined transaction, with the revert reason of failed ones
+func receiptResult(client *ethclient.Client, tx *types.Transaction, from common.Address, receipt *types.Receipt) TradeResult {
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = tx.GasPrice()
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+
+	result := TradeResult{
+		Hash:              receipt.TxHash.Hex(),
+		Status:            "success",
+		Explorer:          explorerLink(tx),
+		Block:             receipt.BlockNumber.Uint64(),
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: gasPrice.String(),
+		Fee:               fee.String(),
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		result.ContractAddress = receipt.ContractAddress.Hex()
+		codeSize := deployedCodeSize(client, receipt)
+		result.CodeSize = &codeSize
+	}
+	for _, log := range receipt.Logs {
+		topics := make([]string, len(log.Topics))
+		for i, topic := range log.Topics {
+			topics[i] = topic.Hex()
+		}
+		result.Logs = append(result.Logs, TradeLog{
+			Index:   log.Index,
+			Address: log.Address.Hex(),
+			Topics:  topics,
+			Data:    hexutil.Encode(log.Data),
+		})
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		result.Status = "reverted"
+		result.RevertReason = strings.TrimSpace(replayRevertReason(client, tx, from, receipt.BlockNumber))
+	}
+	return result
+}
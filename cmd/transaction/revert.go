@@ -0,0 +1,132 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Extract the revert data carried by an RPC error
+func revertData(err error) []byte {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil
+	}
+
+	switch data := dataErr.ErrorData().(type) {
+	case string:
+		decoded, err := hexutil.Decode(data)
+		if err != nil {
+			return nil
+		}
+		return decoded
+	case []byte:
+		return data
+	default:
+		return nil
+	}
+}
+
+// Selectors of the builtin Error(string) and Panic(uint256) reverts
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// ABI file holding the custom errors of the contract
+var errorABI string
+
+// Describe why a call reverted
+func revertReason(err error) string {
+	data := revertData(err)
+	if len(data) == 0 {
+		return err.Error()
+	}
+	return decodeRevert(data)
+}
+
+// Decode Error(string), Panic(uint256) and the custom errors of --error-abi or --abi
+func decodeRevert(data []byte) string {
+	if len(data) < 4 {
+		return "revert data " + hexutil.Encode(data)
+	}
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return reason
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return fmt.Sprintf("Panic(%#x): %s", new(big.Int).SetBytes(data[4:]), reason)
+		}
+	default:
+		if reason, ok := decodeCustomError(data); ok {
+			return reason
+		}
+	}
+	return "revert data " + hexutil.Encode(data) + ", unknown selector " + hexutil.Encode(data[:4])
+}
+
+// Match the selector against the errors of the supplied ABI
+func decodeCustomError(data []byte) (string, bool) {
+	path := errorABI
+	if path == "" {
+		path = contractABI
+	}
+	if path == "" {
+		return "", false
+	}
+	contractAbi, err := utils.LoadABI(path)
+	if err != nil {
+		return "", false
+	}
+
+	for _, customErr := range contractAbi.Errors {
+		if !bytes.Equal(data[:4], customErr.ID[:4]) {
+			continue
+		}
+		values, err := customErr.Inputs.Unpack(data[4:])
+		if err != nil {
+			return "", false
+		}
+		args := make([]string, len(values))
+		for i, value := range values {
+			args[i] = utils.FormatABIValue(value)
+			if name := customErr.Inputs[i].Name; name != "" {
+				args[i] = name + "=" + args[i]
+			}
+		}
+		return customErr.Name + "(" + strings.Join(args, ", ") + ")", true
+	}
+	return "", false
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Simulation is the structured output of --simulate, amounts are in wei
+type Simulation struct {
+	Success      bool   `json:"success"`
+	ReturnData   string `json:"returnData,omitempty"`
+	RevertReason string `json:"revertReason,omitempty"`
+	Balance      string `json:"balance"`
+	Value        string `json:"value"`
+	Fee          string `json:"fee,omitempty"`
+	MaxFee       string `json:"maxFee,omitempty"`
+	BalanceAfter string `json:"balanceAfter,omitempty"`
+}
+
+var simulate bool
+
+func init() {
+	// Add flags
+	TransactionCmd.PersistentFlags().BoolVar(&simulate, "simulate", false, "run the transaction with eth_call at the pending block before signing")
+	TransactionCmd.PersistentFlags().StringVar(&errorABI, "error-abi", "", "ABI JSON file used to decode custom errors (default is --abi)")
+}
+
+// Run the exact transaction at the pending block. It runs before the gas estimate,
+// which fails on a revert without the balance change, so a revert is printed here
+func simulateTx(client *ethclient.Client, trade *Trade) (*Simulation, error) {
+	value, _ := new(big.Int).SetString(trade.Amount, 10)
+	msg := ethereum.CallMsg{
+		From:  trade.FromAddress,
+		To:    trade.To,
+		Gas:   trade.GasLimit,
+		Value: value,
+		Data:  trade.Data,
+	}
+	if trade.Dynamic {
+		msg.GasTipCap = trade.GasTipCap
+		msg.GasFeeCap = trade.GasFeeCap
+	} else {
+		msg.GasPrice = trade.GasPrice
+	}
+
+	result, callErr := client.PendingCallContract(context.Background(), msg)
+	balance, err := client.PendingBalanceAt(context.Background(), trade.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &Simulation{
+		Success: callErr == nil,
+		Balance: balance.String(),
+		Value:   value.String(),
+	}
+	if callErr == nil {
+		if len(result) > 0 {
+			simulation.ReturnData = hexutil.Encode(result)
+		}
+		return simulation, nil
+	}
+
+	simulation.RevertReason = revertReason(callErr)
+	printSimulationResult(*simulation)
+	return nil, errors.New("simulation reverted: " + simulation.RevertReason)
+}
+
+// Add the fees of the estimate to a successful simulation and print it
+func reportSimulation(simulation *Simulation, trade *Trade, baseFee *big.Int, gasUsed uint64) {
+	// The expected fee uses the estimate, the maximum fee the whole gas limit
+	gasPrice, maxGasPrice := trade.GasPrice, trade.GasPrice
+	if trade.Dynamic {
+		gasPrice = new(big.Int).Add(baseFee, trade.GasTipCap)
+		if gasPrice.Cmp(trade.GasFeeCap) > 0 {
+			gasPrice = trade.GasFeeCap
+		}
+		maxGasPrice = trade.GasFeeCap
+	}
+
+	balance, _ := new(big.Int).SetString(simulation.Balance, 10)
+	value, _ := new(big.Int).SetString(simulation.Value, 10)
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasUsed))
+	maxFee := new(big.Int).Mul(maxGasPrice, new(big.Int).SetUint64(trade.GasLimit))
+	after := new(big.Int).Sub(balance, value)
+	after.Sub(after, fee)
+	simulation.Fee, simulation.MaxFee, simulation.BalanceAfter = fee.String(), maxFee.String(), after.String()
+
+	printSimulationResult(*simulation)
+	if balance.Cmp(new(big.Int).Add(value, maxFee)) < 0 {
+		fmt.Fprintln(output.Text, "<-- ⚠️  The balance does not cover the value and the maximum fee -->")
+	}
+}
+
+// Add the simulation to the structured output or print it as text
+func printSimulationResult(simulation Simulation) {
+	if output.Structured() {
+		output.Add("simulation", simulation)
+		return
+	}
+	printSimulation(simulation)
+}
+
+// Print the outcome of the simulation
+func printSimulation(simulation Simulation) {
+	ether := func(wei string) string {
+		return utils.EthNumberConverter(wei, "wei")["ether"] + " " + config.NativeSymbol()
+	}
+
+	fmt.Fprintln(output.Text, "╔══════[ 🧪 Simulation at the pending block ]══════╗")
+	if simulation.Success {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "result", "✅ success")
+	} else {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "result", "❌ reverted")
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "reason", simulation.RevertReason)
+	}
+	if simulation.ReturnData != "" {
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "return", simulation.ReturnData)
+	}
+	fmt.Fprintf(output.Text, "  %-8s: %s\n", "balance", ether(simulation.Balance))
+	fmt.Fprintf(output.Text, "  %-8s: -%s\n", "value", ether(simulation.Value))
+	// A reverted simulation has no gas estimate to price
+	if simulation.Fee != "" {
+		fmt.Fprintf(output.Text, "  %-8s: -%s (at most %s)\n", "fee", ether(simulation.Fee), ether(simulation.MaxFee))
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "after", ether(simulation.BalanceAfter))
+	}
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════════╝")
+}
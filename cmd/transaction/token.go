@@ -0,0 +1,136 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	signer "txtoolbox/cmd/signer"
+	utils "txtoolbox/cmd/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// TokenCmd represents the transaction/token command
+var TokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Transfer ERC-20 tokens using the token's decimals",
+	Example: `
+trade token --token 0xA0b8..eB48 --to 0x.. --amount 12.5:Send 12.5 tokens
+trade token --token 0xA0b8..eB48 --amount 12.5:Send to the to key of the configuration file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/token called")
+		token, err := utils.ParseAddress(tokenAddress)
+		if err != nil {
+			return err
+		}
+
+		recipient := tokenTo
+		if recipient == "" {
+			recipient = viper.GetString("to")
+		}
+		if recipient == "" {
+			return errors.New("please enter a valid recipient with --to")
+		}
+		to, err := utils.ParseAddress(recipient)
+		if err != nil {
+			return err
+		}
+		if to == (common.Address{}) {
+			return errors.New("please enter a valid recipient with --to")
+		}
+
+		trade, err := readInConfig()
+		if err != nil {
+			return err
+		}
+		client, _, err := config.DialNetwork()
+		if err != nil {
+			return err
+		}
+		privateKey, err := signer.LoadKey()
+		if err != nil {
+			return err
+		}
+		trade.Key = privateKey
+		from := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+		// Check the token and the balance
+		info, err := utils.ReadToken(client, token, from)
+		if err != nil {
+			return err
+		}
+		value, err := utils.ParseUnits(tokenAmount, info.Decimals)
+		if err != nil {
+			return err
+		}
+		printTokenTransfer(info, "recipient", to, tokenAmount)
+		if err := checkRecipients(to); err != nil {
+			return err
+		}
+		if info.Balance.Cmp(value) < 0 {
+			return fmt.Errorf("insufficient %s balance: %s < %s", info.Symbol, utils.FormatUnits(info.Balance, info.Decimals), tokenAmount)
+		}
+
+		data, err := utils.PackCall(utils.ERC20Transfer, to, value)
+		if err != nil {
+			return err
+		}
+		trade.To = &token
+		trade.Data = data
+		trade.Amount, trade.AmountUnit = "0", "wei"
+
+		return processConfig(trade)
+	},
+}
+
+var tokenAddress string
+var tokenTo string
+var tokenAmount string
+
+func init() {
+	// Add flags
+	TokenCmd.Flags().StringVar(&tokenAddress, "token", "", "ERC-20 token contract address")
+	TokenCmd.Flags().StringVar(&tokenTo, "to", "", "recipient (default is the to key of the configuration file)")
+	TokenCmd.Flags().StringVarP(&tokenAmount, "amount", "a", "", "amount in whole tokens, such as 12.5")
+	TokenCmd.MarkFlagRequired("token")
+	TokenCmd.MarkFlagRequired("amount")
+}
+
+// Print the token, the balance and the transfer
+func printTokenTransfer(info *utils.TokenInfo, role string, to common.Address, amount string) {
+	toColor, _ := utils.GenAddressColor(to.Hex())
+
+	fmt.Fprintln(output.Text, "╔═══════[ 🪙 Token configuration successful ]═══════╗")
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "token", info.Address.Hex())
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "symbol", info.Symbol)
+	fmt.Fprintf(output.Text, "  %-9s: %d\n", "decimals", info.Decimals)
+	fmt.Fprintf(output.Text, "  %-9s: %s %s\n", "balance", utils.FormatUnits(info.Balance, info.Decimals), info.Symbol)
+	fmt.Fprintf(output.Text, "  %-9s: %s %s\n", "amount", amount, info.Symbol)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", role, toColor)
+	fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════════════╝")
+}
@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -30,11 +31,16 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
 	utils "txtoolbox/cmd/utils"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -47,36 +53,61 @@ var TransactionCmd = &cobra.Command{
 	Use:   "trade",
 	Short: "Use shell to initiate transactions on blockchain directly",
 	Long:  figure.NewFigure("trade", "", true).String(),
-	Run: func(cmd *cobra.Command, args []string) {
-		readInConfig()
-		fmt.Println("transaction/trade called")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "transaction/trade called")
+		trade, err := readInConfig()
+		if err != nil {
+			return err
+		}
+		return processConfig(trade)
 	},
 }
 
 func init() {
 	// Add command
+	TransactionCmd.AddCommand(CallCmd)
+	TransactionCmd.AddCommand(SendCmd)
+	TransactionCmd.AddCommand(SpeedupCmd)
+	TransactionCmd.AddCommand(CancelCmd)
+	TransactionCmd.AddCommand(SignCmd)
+	TransactionCmd.AddCommand(BroadcastCmd)
+	TransactionCmd.AddCommand(BatchCmd)
+	TransactionCmd.AddCommand(TokenCmd)
+	TransactionCmd.AddCommand(DeployCmd)
+	TransactionCmd.AddCommand(PermitCmd)
+	TransactionCmd.AddCommand(ApproveCmd)
+	TransactionCmd.AddCommand(RevokeCmd)
 }
 
 type Trade struct {
 	NetWork     string
 	ChainId     *big.Int
 	FromAddress common.Address
-	Private     string
+	Key         *ecdsa.PrivateKey
 	To          *common.Address
 	Amount      string
+	AmountUnit  string
 	Nonce       uint64
 	GasPrice    *big.Int
+	GasTipCap   *big.Int
+	GasFeeCap   *big.Int
+	Dynamic     bool
 	GasLimit    uint64
 	Data        []byte
 }
 
 // Reading Configuration Files
-func readInConfig() *Trade {
+func readInConfig() (*Trade, error) {
 	trade := new(Trade)
-	trade.NetWork = viper.GetString("netWork")
-	trade.Private = viper.GetString("privateKey")
+	trade.NetWork = config.GetString("netWork")
 	trade.To = new(common.Address)
-	*trade.To = common.HexToAddress(viper.GetString("to"))
+	if to := viper.GetString("to"); to != "" {
+		address, err := utils.ParseAddress(to)
+		if err != nil {
+			return nil, err
+		}
+		*trade.To = address
+	}
 
 	amount := viper.GetString("amount")
 	if amount == "" {
@@ -89,63 +120,67 @@ func readInConfig() *Trade {
 			trade.Amount = amount
 		}
 	}
+	trade.AmountUnit = viper.GetString("amountUint")
 	trade.Nonce = viper.GetUint64("nonce")
 	trade.GasPrice, _ = new(big.Int).SetString(viper.GetString("gasprice"), 10)
+	trade.GasTipCap, _ = new(big.Int).SetString(viper.GetString("maxPriorityFeePerGas"), 10)
+	trade.GasFeeCap, _ = new(big.Int).SetString(viper.GetString("maxFeePerGas"), 10)
 	trade.GasLimit = viper.GetUint64("gaslimit")
 
-	trade.Data = []byte(viper.GetString("data"))
-
-	err := processConfig(trade)
-	if err != nil {
-		fmt.Println(err)
+	// Check data, hex is decoded and anything else is sent as text
+	data := viper.GetString("data")
+	if strings.HasPrefix(data, "0x") {
+		decodedData, err := hex.DecodeString(data[2:])
+		if err != nil {
+			return nil, errors.New("failed to decode data")
+		}
+		trade.Data = decodedData
+	} else if data != "" {
+		trade.Data = []byte(data)
 	}
-	return trade
+
+	return trade, nil
 }
 
 // Processing Configuration Files
 func processConfig(trade *Trade) error {
 	// Check network
-	if trade.NetWork == "" {
-		return errors.New("netWork is empty")
-	}
-
-	client, err := ethclient.Dial(trade.NetWork)
-	if err != nil {
-		return err
-	}
-
-	chainID, err := client.ChainID(context.Background())
+	client, chainID, err := config.DialNetwork()
 	if err != nil {
 		return err
 	}
 	trade.ChainId = chainID
-	fmt.Println("<-- ⛓️  Network connection successful, chainID:", chainID, "-->")
 
-	// Check privateKey
-	if trade.Private == "" {
-		return errors.New("privateKey is empty")
-	}
-
-	private := strings.TrimLeft(trade.Private, "0x")
-	privateKey, err := crypto.HexToECDSA(private)
-	if err != nil {
-		return err
+	// Check signer, commands that needed the key earlier have already loaded it
+	if trade.Key == nil {
+		trade.Key, err = signer.LoadKey()
+		if err != nil {
+			return err
+		}
 	}
+	privateKey := trade.Key
 	privateToAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
 	privateToAddrColor, _ := utils.GenAddressColor(privateToAddr.String())
 	trade.FromAddress = privateToAddr
-	fmt.Println("<-- 🥷  Private key configuration successful:", privateToAddrColor, "-->")
+	fmt.Fprintln(output.Text, "<-- 🥷  Private key configuration successful:", privateToAddrColor, "-->")
 
-	// Check to address
-	to := trade.To.String()
-	if to == new(common.Address).String() || len(to) != 42 {
-		return errors.New("to address is empty")
+	// Check to address, contract creations have none
+	if trade.To == nil {
+		fmt.Fprintln(output.Text, "<-- 🏗️  Contract creation, the address follows from the nonce -->")
+	} else {
+		to := trade.To.String()
+		if to == new(common.Address).String() || len(to) != 42 {
+			return errors.New("to address is empty")
+		}
+		toAddrColcor, _ := utils.GenAddressColor(to)
+		fmt.Fprintln(output.Text, "<-- 💸 To Address configuration successful:", toAddrColcor, "-->")
+		if err := checkRecipients(*trade.To); err != nil {
+			return err
+		}
 	}
-	toAddrColcor, _ := utils.GenAddressColor(to)
-	fmt.Println("<-- 💸 To Address configuration successful:", toAddrColcor, "-->")
 
 	// Check uints and amount
-	amountUints := viper.GetString("amountUint")
+	amountUints := trade.AmountUnit
 	if amountUints == "" || amountUints == "wei" {
 		if strings.Contains(trade.Amount, ".") {
 			return errors.New("the default unit is wei, and decimals are displayed for amounts")
@@ -153,18 +188,25 @@ func processConfig(trade *Trade) error {
 	}
 	if utils.UnitMultipliers[amountUints] == "" {
 		if trade.Amount != "0" {
-			fmt.Println("<-- 💵 Amount Configuration Successful:", trade.Amount, "wei -->")
+			fmt.Fprintln(output.Text, "<-- 💵 Amount Configuration Successful:", trade.Amount, "wei -->")
 		}
 	} else {
 		if trade.Amount != "0" {
 			uintsMap := utils.EthNumberConverter(trade.Amount, amountUints)
-			fmt.Println("╔══[ 💵 Amount Configuration Successful ]══╗")
-			fmt.Printf("  %-6s: %v wei\n", "wei", uintsMap["wei"])
-			fmt.Printf("  %-6s: %v %s\n", amountUints, uintsMap[amountUints], amountUints)
-			fmt.Println("╚══════════════════════════════════════════╝")
+			fmt.Fprintln(output.Text, "╔══[ 💵 Amount Configuration Successful ]══╗")
+			fmt.Fprintf(output.Text, "  %-6s: %v wei\n", "wei", uintsMap["wei"])
+			fmt.Fprintf(output.Text, "  %-6s: %v %s\n", amountUints, uintsMap[amountUints], amountUints)
+			fmt.Fprintln(output.Text, "╚══════════════════════════════════════════╝")
 		}
 	}
 
+	// The amount is in amountUint and sent in wei
+	value, err := utils.ToWei(trade.Amount, amountUints)
+	if err != nil {
+		return err
+	}
+	trade.Amount, trade.AmountUnit = value.String(), "wei"
+
 	// Check nonce
 	clientNonce, err := client.PendingNonceAt(context.Background(), privateToAddr)
 	if err != nil {
@@ -173,26 +215,117 @@ func processConfig(trade *Trade) error {
 	if trade.Nonce == 0 {
 		trade.Nonce = clientNonce
 	} else {
-		r := chrckInputsAndEst(trade.Nonce, clientNonce)
+		r, err := chrckInputsAndEst(trade.Nonce, clientNonce)
+		if err != nil {
+			return err
+		}
 		trade.Nonce = r.(uint64)
 	}
 
-	// Check data
-	data := viper.GetString("data")
-	if trade.Data != nil {
-		if strings.HasPrefix(data, "0x") {
-			// Convert the input hexadecimal string to a byte array
-			decodedData, err := hex.DecodeString(data[2:])
-			if err != nil {
-				return errors.New("failed to decode data")
-			}
-			trade.Data = decodedData
+	// Check gas fees, legacy gasPrice is only used when the chain has no base fee
+	baseFee, err := nextBaseFee(client)
+	if err != nil {
+		return err
+	}
+	if baseFee == nil {
+		err = checkGasPrice(client, trade)
+	} else {
+		err = checkDynamicFees(client, trade, baseFee)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Simulate the exact transaction before the estimate, which fails on a revert without the details
+	var simulation *Simulation
+	if simulate {
+		if simulation, err = simulateTx(client, trade); err != nil {
+			return err
 		}
+	}
+
+	// Check gasLimit
+	gasLimit, err := estimateTxGas(client, trade)
+	if err != nil {
+		return err
+	}
+	if trade.GasLimit == 0 {
+		trade.GasLimit = gasLimit
 	} else {
-		trade.Data = nil
+		r, err := chrckInputsAndEst(trade.GasLimit, gasLimit)
+		if err != nil {
+			return err
+		}
+		trade.GasLimit = r.(uint64)
+	}
+
+	gasLimitString := strconv.FormatUint(trade.GasLimit, 10)
+	uintsMap := utils.EthNumberConverter(gasLimitString, "gwei")
+	fmt.Fprintln(output.Text, "╔═[ 🏦 GasLimit configuration successful ]═╗")
+	fmt.Fprintf(output.Text, "  %-6s: %v wei\n", "wei", uintsMap["wei"])
+	fmt.Fprintf(output.Text, "  %-6s: %v %s\n", "gwei", uintsMap["gwei"], "gwei")
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════╝")
+
+	fmt.Fprintln(output.Text, "<-- 🪤  Nonce configuration successful:", trade.Nonce, "-->")
+
+	if trade.To == nil {
+		contractColor, _ := utils.GenAddressColor(crypto.CreateAddress(trade.FromAddress, trade.Nonce).Hex())
+		fmt.Fprintln(output.Text, "<-- 🏗️  Contract address predicted:", contractColor, "-->")
+		fmt.Fprintln(output.Text, "<-- 📝 Data configuration successful:", len(trade.Data), "bytes of init code -->")
+	} else if len(trade.Data) > 0 {
+		fmt.Fprintln(output.Text, "<-- 📝 Data configuration successful:", hexutil.Encode(trade.Data), "-->")
 	}
 
-	// Check gasPrice
+	// Show the simulation with its fees before asking to sign it
+	if simulation != nil {
+		reportSimulation(simulation, trade, baseFee, gasLimit)
+	}
+
+	if output.Structured() {
+		output.Add("summary", tradeSummary(trade))
+	}
+
+	start, err := prompt.Confirm("Start transaction?")
+	if err != nil {
+		return err
+	}
+	if !start {
+		os.Exit(0)
+	}
+	return initiateTx(client, trade)
+}
+
+// Warn when recipients look like poisoned variants of saved addresses
+func checkRecipients(recipients ...common.Address) error {
+	lookalike := false
+	for _, to := range recipients {
+		scan := utils.ScanAddress(to.Hex(), utils.DefaultLookalikeChars)
+		if len(scan.Lookalikes) > 0 || (scan.Known != "" && len(recipients) == 1) {
+			utils.PrintAddressScan(scan)
+		}
+		lookalike = lookalike || len(scan.Lookalikes) > 0
+	}
+	if !lookalike {
+		return nil
+	}
+
+	// --yes alone never sends to a lookalike
+	policy := prompt.LookalikePolicy
+	if policy == prompt.PolicyAsk && prompt.AssumeYes {
+		policy = prompt.PolicyFail
+	}
+	ok, err := prompt.Decide("The recipient looks like a saved address, continue anyway?", policy, prompt.PolicyContinue, "")
+	if err != nil {
+		return fmt.Errorf("%w, use --on-lookalike=continue to accept it", err)
+	}
+	if !ok {
+		os.Exit(0)
+	}
+	return nil
+}
+
+// Check the legacy gasPrice
+func checkGasPrice(client *ethclient.Client, trade *Trade) error {
 	gasPrice, err := client.SuggestGasPrice(context.Background())
 	if err != nil {
 		return err
@@ -201,96 +334,98 @@ func processConfig(trade *Trade) error {
 	if trade.GasPrice == nil {
 		trade.GasPrice = gasPrice
 	} else {
-		r := chrckInputsAndEst(trade.GasPrice, gasPrice)
+		r, err := chrckInputsAndEst(trade.GasPrice, gasPrice)
+		if err != nil {
+			return err
+		}
 		trade.GasPrice = r.(*big.Int)
 	}
 	uintsMap := utils.EthNumberConverter(trade.GasPrice.String(), "wei")
-	fmt.Println("╔═[ 💰 GasPrice configuration successful ]═╗")
-	fmt.Printf("  %-6s: %v wei\n", "wei", trade.GasPrice.String())
-	fmt.Printf("  %-6s: %v %s\n", "gwei", uintsMap["gwei"], "gwei")
-	fmt.Println("╚══════════════════════════════════════════╝")
+	fmt.Fprintln(output.Text, "╔═[ 💰 GasPrice configuration successful ]═╗")
+	fmt.Fprintf(output.Text, "  %-6s: %v wei\n", "wei", trade.GasPrice.String())
+	fmt.Fprintf(output.Text, "  %-6s: %v %s\n", "gwei", uintsMap["gwei"], "gwei")
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════╝")
+	return nil
+}
 
-	// Check gasLimit
-	gasLimit, err := estimateTxGas(client, trade)
+// Check the EIP-1559 maxPriorityFeePerGas and maxFeePerGas
+func checkDynamicFees(client *ethclient.Client, trade *Trade, baseFee *big.Int) error {
+	trade.Dynamic = true
+
+	gasTipCap, gasFeeCap, err := suggestDynamicFees(client, baseFee)
 	if err != nil {
 		return err
 	}
-	if trade.GasLimit == 0 {
-		trade.GasLimit = gasLimit
+
+	// A configured gasprice acts as the fee cap when no EIP-1559 key is set
+	if trade.GasTipCap == nil && trade.GasFeeCap == nil && trade.GasPrice != nil {
+		fmt.Fprintln(output.Text, "<-- ⛽ gasprice is used as maxFeePerGas on an EIP-1559 chain -->")
+		trade.GasFeeCap = trade.GasPrice
+	}
+	trade.GasPrice = nil
+
+	if trade.GasTipCap == nil {
+		trade.GasTipCap = gasTipCap
 	} else {
-		r := chrckInputsAndEst(trade.GasLimit, gasLimit)
-		trade.GasLimit = r.(uint64)
+		r, err := chrckInputsAndEst(trade.GasTipCap, gasTipCap)
+		if err != nil {
+			return err
+		}
+		trade.GasTipCap = r.(*big.Int)
 	}
 
-	gasLimitString := strconv.FormatUint(trade.GasLimit, 10)
-	uintsMap = utils.EthNumberConverter(gasLimitString, "gwei")
-	fmt.Println("╔═[ 🏦 GasLimit configuration successful ]═╗")
-	fmt.Printf("  %-6s: %v wei\n", "wei", uintsMap["wei"])
-	fmt.Printf("  %-6s: %v %s\n", "gwei", uintsMap["gwei"], "gwei")
-	fmt.Println("╚══════════════════════════════════════════╝")
-
-	fmt.Println("<-- 🪤  Nonce configuration successful:", trade.Nonce, "-->")
-
-	if len(trade.Data) > 0 {
-		fmt.Println("<-- 📝 Data configuration successful:", data, "-->")
-	}
-
-	for {
-		fmt.Println("Start transaction? (Y/y/N/n)")
-
-		var next string
-		fmt.Scanln(&next)
-
-		switch next {
-		case "Y", "y":
-			err = initiateTx(client, trade)
-			if err != nil {
-				return err
-			}
-			return nil
-		case "N", "n":
-			os.Exit(0)
-		default:
-			continue
+	if trade.GasFeeCap == nil {
+		trade.GasFeeCap = gasFeeCap
+	} else {
+		r, err := chrckInputsAndEst(trade.GasFeeCap, gasFeeCap)
+		if err != nil {
+			return err
 		}
+		trade.GasFeeCap = r.(*big.Int)
+	}
+
+	if trade.GasTipCap.Cmp(trade.GasFeeCap) > 0 {
+		return errors.New("maxPriorityFeePerGas cannot be greater than maxFeePerGas")
 	}
+
+	fmt.Fprintln(output.Text, "╔═[ 💰 EIP-1559 fee configuration successful ]═╗")
+	fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "baseFee", utils.EthNumberConverter(baseFee.String(), "wei")["gwei"])
+	fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "maxFee", utils.EthNumberConverter(trade.GasFeeCap.String(), "wei")["gwei"])
+	fmt.Fprintf(output.Text, "  %-8s: %v gwei\n", "tip", utils.EthNumberConverter(trade.GasTipCap.String(), "wei")["gwei"])
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+	return nil
 }
 
 // Check inputs and estimates
-func chrckInputsAndEst(number1, number2 any) any {
-	fmt.Println("Estimated content:", number2, ",Input content:", number1)
+func chrckInputsAndEst(number1, number2 any) (any, error) {
+	fmt.Fprintln(output.Text, "Estimated content:", number2, ",Input content:", number1)
 
 	switch number1.(type) {
 	case *big.Int:
 		if number1.(*big.Int).Cmp(number2.(*big.Int)) == -1 {
 			return handleUserChoice(number1, number2)
 		}
-		return number1
+		return number1, nil
 	case uint64:
 		if number1.(uint64) < number2.(uint64) {
 			return handleUserChoice(number1, number2)
 		}
-		return number1
+		return number1, nil
 	default:
-		return number1
+		return number1, nil
 	}
 }
 
-func handleUserChoice(number1, number2 any) any {
-	fmt.Println("The input is less than the estimate, should we use the estimate? (Y/y/N/n)")
-	for {
-		var next string
-		fmt.Scanln(&next)
-
-		switch next {
-		case "Y", "y":
-			return number2
-		case "N", "n":
-			return number1
-		default:
-			fmt.Println("Please enter Y/y or N/n.")
-		}
+// Choose between the input and the estimate, following --on-low-input when set
+func handleUserChoice(number1, number2 any) (any, error) {
+	useEstimate, err := prompt.Decide("The input is less than the estimate, should we use the estimate?", prompt.LowInputPolicy, prompt.PolicyEstimate, prompt.PolicyInput)
+	if err != nil {
+		return nil, err
+	}
+	if useEstimate {
+		return number2, nil
 	}
+	return number1, nil
 }
 
 // Estimate the gas limit
@@ -298,57 +433,82 @@ func estimateTxGas(client *ethclient.Client, trade *Trade) (uint64, error) {
 	value, _ := new(big.Int).SetString(trade.Amount, 10)
 
 	callMsg := ethereum.CallMsg{
-		From:     trade.FromAddress,
-		To:       trade.To,
-		GasPrice: trade.GasPrice,
-		Value:    value,
-		Data:     trade.Data,
+		From:  trade.FromAddress,
+		To:    trade.To,
+		Value: value,
+		Data:  trade.Data,
+	}
+	if trade.Dynamic {
+		callMsg.GasTipCap = trade.GasTipCap
+		callMsg.GasFeeCap = trade.GasFeeCap
+	} else {
+		callMsg.GasPrice = trade.GasPrice
 	}
 
 	gasLimit, err := client.EstimateGas(context.Background(), callMsg)
 	if err != nil {
+		// Decode the revert instead of showing the raw RPC error
+		if data := revertData(err); len(data) > 0 {
+			return uint64(0), errors.New("gas estimation reverted: " + decodeRevert(data))
+		}
 		return uint64(0), err
 	}
 	return gasLimit, nil
 }
 
+// Build a dynamic fee transaction, or a legacy one when the chain has no base fee
+func buildTx(trade *Trade) *types.Transaction {
+	amount, _ := new(big.Int).SetString(trade.Amount, 10)
+
+	if trade.Dynamic {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   trade.ChainId,
+			Nonce:     trade.Nonce,
+			GasTipCap: trade.GasTipCap,
+			GasFeeCap: trade.GasFeeCap,
+			Gas:       trade.GasLimit,
+			To:        trade.To,
+			Value:     amount,
+			Data:      trade.Data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    trade.Nonce,
+		GasPrice: trade.GasPrice,
+		Gas:      trade.GasLimit,
+		To:       trade.To,
+		Value:    amount,
+		Data:     trade.Data,
+	})
+}
+
 // Initiate a transaction
 func initiateTx(client *ethclient.Client, trade *Trade) error {
 
 	// Create the transaction
-	amount, _ := new(big.Int).SetString(trade.Amount, 10)
-	tx := types.NewTransaction(trade.Nonce, *trade.To, amount, trade.GasLimit, trade.GasPrice, trade.Data)
+	tx := buildTx(trade)
 
 	// Sing the transaction
-	private := strings.TrimLeft(trade.Private, "0x")
-	privateKey, _ := crypto.HexToECDSA(private)
-
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(trade.ChainId), privateKey)
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(trade.ChainId), trade.Key)
 	if err != nil {
 		return errors.New("signature transaction failed")
 	}
 
-	fmt.Println("<-- 📝 Tx hash configuration successful:", signedTx.Hash().Hex(), "-->")
-
-	for {
-		fmt.Println("Send transaction? (Y/y/N/n)")
+	fmt.Fprintln(output.Text, "<-- 📝 Tx hash configuration successful:", signedTx.Hash().Hex(), "-->")
 
-		var next string
-		fmt.Scanln(&next)
+	send, err := prompt.Confirm("Send transaction?")
+	if err != nil {
+		return err
+	}
+	if !send {
+		os.Exit(0)
+	}
 
-		switch next {
-		case "Y", "y":
-			// Send the transaction
-			err = client.SendTransaction(context.Background(), signedTx)
-			if err != nil {
-				return err
-			}
-			fmt.Println("<-- 🚀 Transaction sent-->")
-			return nil
-		case "N", "n":
-			os.Exit(0)
-		default:
-			continue
-		}
+	// Send the transaction
+	err = client.SendTransaction(context.Background(), signedTx)
+	if err != nil {
+		return err
 	}
+	fmt.Fprintln(output.Text, "<-- 🚀 Transaction sent-->")
+	return waitAndReport(client, signedTx, trade.FromAddress)
 }
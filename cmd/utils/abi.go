@@ -0,0 +1,530 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Solidity keywords that may appear around a parameter type
+var paramKeywords = map[string]bool{
+	"indexed":  true,
+	"memory":   true,
+	"calldata": true,
+	"storage":  true,
+	"payable":  true,
+}
+
+// Bare int/uint are aliases of int256/uint256
+var bareIntRegex = regexp.MustCompile(`^(u?int)(\[|$)`)
+
+// Load an ABI from a JSON file, either a plain ABI array or a compiled artifact with an "abi" field
+func LoadABI(path string) (abi.ABI, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	return ParseABI(content)
+}
+
+// Parse an ABI from JSON, either a plain ABI array or a compiled artifact with an "abi" field
+func ParseABI(content []byte) (abi.ABI, error) {
+	var parsed abi.ABI
+	if err := json.Unmarshal(content, &parsed); err == nil {
+		return parsed, nil
+	}
+
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(content, &artifact); err != nil || len(artifact.ABI) == 0 {
+		return abi.ABI{}, errors.New("please enter a valid ABI file")
+	}
+	if err := json.Unmarshal(artifact.ABI, &parsed); err != nil {
+		return abi.ABI{}, err
+	}
+	return parsed, nil
+}
+
+// Load the ABI and creation bytecode of a Foundry or Hardhat artifact
+func LoadArtifact(path string) (abi.ABI, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return abi.ABI{}, nil, err
+	}
+	contractAbi, err := ParseABI(content)
+	if err != nil {
+		return abi.ABI{}, nil, err
+	}
+
+	var artifact struct {
+		Bytecode json.RawMessage `json:"bytecode"`
+	}
+	if err := json.Unmarshal(content, &artifact); err != nil || len(artifact.Bytecode) == 0 {
+		return abi.ABI{}, nil, errors.New("the artifact has no bytecode:<" + path + ">")
+	}
+
+	// Hardhat stores the bytecode as a string, Foundry as an object
+	var bytecode string
+	if err := json.Unmarshal(artifact.Bytecode, &bytecode); err != nil {
+		var object struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(artifact.Bytecode, &object); err != nil {
+			return abi.ABI{}, nil, errors.New("the artifact has no bytecode:<" + path + ">")
+		}
+		bytecode = object.Object
+	}
+
+	code, err := DecodeBytecode(bytecode)
+	if err != nil {
+		return abi.ABI{}, nil, err
+	}
+	return contractAbi, code, nil
+}
+
+// Read creation bytecode given as hex or as a file holding it, such as the .bin output of solc
+func ReadBytecode(input string) ([]byte, error) {
+	if !strings.HasPrefix(input, "0x") {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			return nil, err
+		}
+		input = string(content)
+	}
+	return DecodeBytecode(input)
+}
+
+// Decode hex creation bytecode, refusing unlinked library placeholders
+func DecodeBytecode(bytecode string) ([]byte, error) {
+	bytecode = strings.TrimPrefix(strings.TrimSpace(bytecode), "0x")
+	if strings.Contains(bytecode, "__") {
+		return nil, errors.New("the bytecode has unlinked libraries, link them before deploying")
+	}
+	if bytecode == "" {
+		return nil, errors.New("the bytecode is empty, abstract contracts and interfaces cannot be deployed")
+	}
+	code, err := hexutil.Decode("0x" + bytecode)
+	if err != nil {
+		return nil, errors.New("please enter valid bytecode")
+	}
+	return code, nil
+}
+
+// Find a method in the ABI by name, raw name or full signature
+func FindMethod(contractAbi abi.ABI, name string) (abi.Method, error) {
+	if method, ok := contractAbi.Methods[name]; ok {
+		return method, nil
+	}
+
+	var found []abi.Method
+	for _, method := range contractAbi.Methods {
+		if method.Sig == name || method.RawName == name {
+			found = append(found, method)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %s not found in ABI", name)
+	case 1:
+		return found[0], nil
+	default:
+		return abi.Method{}, fmt.Errorf("method %s is overloaded, use the full signature such as %s", name, found[0].Sig)
+	}
+}
+
+// Parse a human readable function signature such as
+// transfer(address,uint256) or balanceOf(address)(uint256) or
+// function balanceOf(address owner) view returns (uint256)
+func ParseMethodSignature(signature string) (abi.Method, error) {
+	signature = strings.TrimSpace(signature)
+	signature = strings.TrimPrefix(signature, "function ")
+
+	open := strings.Index(signature, "(")
+	if open <= 0 {
+		return abi.Method{}, fmt.Errorf("please enter a valid function signature:<%s>", signature)
+	}
+	name := strings.TrimSpace(signature[:open])
+
+	closed, err := matchParen(signature, open)
+	if err != nil {
+		return abi.Method{}, err
+	}
+	inputs, err := ParseArguments(signature[open+1 : closed])
+	if err != nil {
+		return abi.Method{}, err
+	}
+
+	// Modifiers and return values follow the inputs
+	var outputs abi.Arguments
+	rest := signature[closed+1:]
+	modifiers := rest
+	if i := strings.Index(rest, "("); i >= 0 {
+		modifiers = rest[:i]
+		end, err := matchParen(rest, i)
+		if err != nil {
+			return abi.Method{}, err
+		}
+		outputs, err = ParseArguments(rest[i+1 : end])
+		if err != nil {
+			return abi.Method{}, err
+		}
+	}
+
+	mutability := "nonpayable"
+	for _, word := range strings.Fields(modifiers) {
+		switch word {
+		case "view", "pure", "payable":
+			mutability = word
+		}
+	}
+
+	return abi.NewMethod(name, name, abi.Function, mutability, mutability == "view" || mutability == "pure", mutability == "payable", inputs, outputs), nil
+}
+
+// Parse a comma separated parameter list such as "address to,(uint256,bool)[] items"
+func ParseArguments(list string) (abi.Arguments, error) {
+	var arguments abi.Arguments
+	for _, part := range splitTopLevel(list) {
+		marshaling, err := parseArgumentMarshaling(part)
+		if err != nil {
+			return nil, err
+		}
+		t, err := abi.NewType(marshaling.Type, marshaling.InternalType, marshaling.Components)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, abi.Argument{Name: marshaling.Name, Type: t, Indexed: marshaling.Indexed})
+	}
+	return arguments, nil
+}
+
+// Parse one parameter into the JSON ABI representation
+func parseArgumentMarshaling(part string) (abi.ArgumentMarshaling, error) {
+	var marshaling abi.ArgumentMarshaling
+	part = strings.TrimSpace(part)
+
+	var words []string
+	if strings.HasPrefix(part, "(") || strings.HasPrefix(part, "tuple(") {
+		part = strings.TrimPrefix(part, "tuple")
+		closed, err := matchParen(part, 0)
+		if err != nil {
+			return marshaling, err
+		}
+		for i, component := range splitTopLevel(part[1:closed]) {
+			c, err := parseArgumentMarshaling(component)
+			if err != nil {
+				return marshaling, err
+			}
+			// Anonymous tuple fields cannot be mapped to a struct
+			if c.Name == "" {
+				c.Name = "field" + strconv.Itoa(i)
+			}
+			marshaling.Components = append(marshaling.Components, c)
+		}
+
+		words = strings.Fields(part[closed+1:])
+		marshaling.Type = "tuple"
+		if len(words) > 0 && strings.HasPrefix(words[0], "[") {
+			marshaling.Type += words[0]
+			words = words[1:]
+		}
+	} else {
+		words = strings.Fields(part)
+		if len(words) == 0 {
+			return marshaling, errors.New("empty parameter type")
+		}
+		marshaling.Type = bareIntRegex.ReplaceAllString(words[0], "${1}256$2")
+		words = words[1:]
+	}
+
+	for _, word := range words {
+		if word == "indexed" {
+			marshaling.Indexed = true
+		}
+		if !paramKeywords[word] {
+			marshaling.Name = word
+		}
+	}
+	return marshaling, nil
+}
+
+// Find the parenthesis closing the one at index open
+func matchParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses:<%s>", s)
+}
+
+// Split a list on commas that are not nested in brackets or quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '(', '[':
+			if !quoted {
+				depth++
+			}
+		case ')', ']':
+			if !quoted {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" || len(parts) > 0 {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// Convert command line strings into values the ABI encoder accepts
+func ParseArgValues(arguments abi.Arguments, values []string) ([]any, error) {
+	if len(arguments) != len(values) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(arguments), len(values))
+	}
+
+	results := make([]any, len(values))
+	for i, argument := range arguments {
+		value, err := ParseArgValue(argument.Type, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %v", i, argument.Type.String(), err)
+		}
+		results[i] = value.Interface()
+	}
+	return results, nil
+}
+
+// Convert one command line string into a value of the given ABI type
+// Arrays are written as [a,b] and tuples as (a,b)
+func ParseArgValue(t abi.Type, s string) (reflect.Value, error) {
+	s = strings.TrimSpace(s)
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid integer <%s>", s)
+		}
+		if err := checkIntRange(n, t); err != nil {
+			return reflect.Value{}, err
+		}
+		if t.GetType() == reflect.TypeOf(n) {
+			return reflect.ValueOf(n), nil
+		}
+		if t.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(t.GetType()), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(t.GetType()), nil
+
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bool <%s>", s)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			s = unquoted
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.AddressTy:
+		address, err := ParseAddress(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(address), nil
+
+	case abi.BytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes <%s>: %v", s, err)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes <%s>: %v", s, err)
+		}
+		array := reflect.New(t.GetType()).Elem()
+		if len(b) != array.Len() {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes, got %d", array.Len(), len(b))
+		}
+		reflect.Copy(array, reflect.ValueOf(b))
+		return array, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+			return reflect.Value{}, fmt.Errorf("arrays are written as [a,b], got <%s>", s)
+		}
+		items := splitTopLevel(s[1 : len(s)-1])
+		var list reflect.Value
+		if t.T == abi.SliceTy {
+			list = reflect.MakeSlice(t.GetType(), len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d items, got %d", t.Size, len(items))
+			}
+			list = reflect.New(t.GetType()).Elem()
+		}
+		for i, item := range items {
+			value, err := ParseArgValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			list.Index(i).Set(value)
+		}
+		return list, nil
+
+	case abi.TupleTy:
+		if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+			return reflect.Value{}, fmt.Errorf("tuples are written as (a,b), got <%s>", s)
+		}
+		items := splitTopLevel(s[1 : len(s)-1])
+		if len(items) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("expected %d tuple fields, got %d", len(t.TupleElems), len(items))
+		}
+		tuple := reflect.New(t.GetType()).Elem()
+		for i, item := range items {
+			value, err := ParseArgValue(*t.TupleElems[i], item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			tuple.Field(i).Set(value)
+		}
+		return tuple, nil
+
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t.String())
+	}
+}
+
+// Check that an integer fits into the ABI type
+func checkIntRange(n *big.Int, t abi.Type) error {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("%s out of range for %s", n, t.String())
+		}
+		return nil
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s out of range for %s", n, t.String())
+	}
+	return nil
+}
+
+// Format a decoded ABI value, arrays as [a, b] and tuples as (a, b)
+func FormatABIValue(v any) string {
+	switch value := v.(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	case string:
+		return strconv.Quote(value)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		// Fixed bytes are shown as hex
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = FormatABIValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Struct:
+		items := make([]string, rv.NumField())
+		for i := range items {
+			items[i] = FormatABIValue(rv.Field(i).Interface())
+		}
+		return "(" + strings.Join(items, ", ") + ")"
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "nil"
+		}
+		return FormatABIValue(rv.Elem().Interface())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Print decoded ABI values next to their types and names
+func PrintABIValues(arguments abi.Arguments, values []any) {
+	for i, argument := range arguments {
+		if i >= len(values) {
+			return
+		}
+		name := argument.Name
+		if name != "" {
+			name = " " + name
+		}
+		fmt.Fprintf(output.Text, "  [%d] %s%s: %s\n", i, argument.Type.String(), name, FormatABIValue(values[i]))
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// AddressChecksumCmd represents the utils/checkAddress/checksum command
+var AddressChecksumCmd = &cobra.Command{
+	Use:   "checksum",
+	Short: "Check the checksum of an address and print its EIP-55 and EIP-1191 forms",
+	Example: `
+utils checkAddress checksum -a 0x..:Print the EIP-55 form
+utils checkAddress checksum -a 0x.. --chain-id 30:Print the EIP-1191 form of RSK`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/checksum called")
+		address := strings.TrimSpace(addressLeft)
+		if !hexAddressRegex.MatchString(address) {
+			return errors.New("please enter a valid address:<" + address + ">")
+		}
+
+		// The chain ID of the active profile is used when not given
+		chainID := configuredChainID()
+		if checksumChainID != "" {
+			var ok bool
+			if chainID, ok = new(big.Int).SetString(checksumChainID, 10); !ok {
+				return errors.New("Check the chain ID entered:<" + checksumChainID + ">")
+			}
+		}
+
+		parsed := common.HexToAddress(address)
+		result := AddressChecksum{
+			Input:     address,
+			Lowercase: strings.ToLower(address),
+			EIP55:     ChecksumAddress(parsed, nil),
+			Checksum:  ChecksumKind(address, chainID),
+		}
+		if chainID != nil {
+			result.ChainID = chainID.String()
+			result.EIP1191 = ChecksumAddress(parsed, chainID)
+		}
+
+		if output.Structured() {
+			return output.Print(result)
+		}
+		status := map[string]string{
+			"none":     "⚪ no checksum, the address is in a single case",
+			"eip55":    "✅ valid EIP-55",
+			"eip1191":  "✅ valid EIP-1191 for chain " + result.ChainID,
+			"mismatch": "❌ the checksum does not match",
+		}
+		eip55Color, _ := GenAddressColor(result.EIP55)
+		fmt.Fprintln(output.Text, "╔═════════════[ ✅ Address checksum ]═════════════╗")
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "input", result.Input)
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "checksum", status[result.Checksum])
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "lowercase", result.Lowercase)
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "EIP-55", eip55Color)
+		if result.EIP1191 != "" {
+			fmt.Fprintf(output.Text, "  %-9s: %s (chain %s)\n", "EIP-1191", result.EIP1191, result.ChainID)
+		} else {
+			fmt.Fprintf(output.Text, "  %-9s: %s\n", "EIP-1191", "use --chain-id for the chain specific form")
+		}
+		fmt.Fprintln(output.Text, "╚════════════════════════════════════════════════╝")
+		return nil
+	},
+}
+
+// AddressChecksum is the structured output of checksum
+type AddressChecksum struct {
+	Input     string `json:"input"`
+	Checksum  string `json:"checksum"`
+	Lowercase string `json:"lowercase"`
+	EIP55     string `json:"eip55"`
+	ChainID   string `json:"chainId,omitempty"`
+	EIP1191   string `json:"eip1191,omitempty"`
+}
+
+var checksumChainID string
+
+func init() {
+	// Add command
+	CheckAddressCmd.AddCommand(AddressChecksumCmd)
+
+	// Add flags
+	AddressChecksumCmd.Flags().StringVarP(&addressLeft, "address", "a", "", "address")
+	AddressChecksumCmd.Flags().StringVar(&checksumChainID, "chain-id", "", "chain ID of the EIP-1191 form (default is the chainId key)")
+	AddressChecksumCmd.MarkFlagRequired("address")
+}
+
+// Returned when a mixed-case address matches neither EIP-55 nor EIP-1191
+var ErrChecksum = errors.New("address checksum mismatch")
+
+var hexAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Parse an address strictly, mixed-case addresses must carry a valid checksum
+func ParseAddress(address string) (common.Address, error) {
+	return ParseAddressPolicy(address, prompt.BadChecksumPolicy)
+}
+
+// Parse an address, a bad checksum fails or only warns depending on the policy.
+// The address tools warn, comparing suspicious addresses is what they are for
+func ParseAddressPolicy(address, policy string) (common.Address, error) {
+	address = strings.TrimSpace(address)
+	if !hexAddressRegex.MatchString(address) {
+		return common.Address{}, errors.New("please enter a valid address:<" + address + ">")
+	}
+	parsed := common.HexToAddress(address)
+	if ChecksumKind(address, configuredChainID()) != "mismatch" {
+		return parsed, nil
+	}
+
+	err := fmt.Errorf("%w:<%s>, the EIP-55 form is %s", ErrChecksum, address, parsed.Hex())
+	if policy == prompt.PolicyWarn {
+		fmt.Fprintln(output.Text, "<-- ⚠️ ", err, "-->")
+		return parsed, nil
+	}
+	return common.Address{}, err
+}
+
+// How an address is checksummed: none (single case), eip55, eip1191 or mismatch
+func ChecksumKind(address string, chainID *big.Int) string {
+	body := address[2:]
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return "none"
+	}
+	parsed := common.HexToAddress(address)
+	if address == ChecksumAddress(parsed, nil) {
+		return "eip55"
+	}
+	if chainID != nil && address == ChecksumAddress(parsed, chainID) {
+		return "eip1191"
+	}
+	return "mismatch"
+}
+
+// The EIP-55 checksum form, or the EIP-1191 form of a chain when chainID is set
+func ChecksumAddress(address common.Address, chainID *big.Int) string {
+	lower := common.Bytes2Hex(address.Bytes())
+	prefix := ""
+	if chainID != nil {
+		prefix = chainID.String() + "0x"
+	}
+	hash := crypto.Keccak256([]byte(prefix + lower))
+
+	result := []byte(lower)
+	for i, c := range result {
+		// Letters are upper case when the matching nibble of the hash is at least 8
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && nibble&0xf >= 8 {
+			result[i] = c - 32
+		}
+	}
+	return "0x" + string(result)
+}
+
+// The chain ID of the active profile or configuration file, if any
+func configuredChainID() *big.Int {
+	chainID, ok := new(big.Int).SetString(config.GetString("chainId"), 10)
+	if !ok {
+		return nil
+	}
+	return chainID
+}
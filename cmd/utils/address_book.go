@@ -0,0 +1,231 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AddressBookCmd represents the utils/checkAddress/book command
+var AddressBookCmd = &cobra.Command{
+	Use:   "book",
+	Short: "Save known addresses under a label",
+	Example: `
+utils checkAddress book add -l alice -a 0x..:Save an address
+utils checkAddress book list:List saved addresses
+utils checkAddress book remove -l alice:Remove an address`,
+}
+
+// AddressBookAddCmd represents the utils/checkAddress/book/add command
+var AddressBookAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Save an address under a label",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/book/add called")
+		label := strings.ToLower(bookLabel)
+		if !bookLabelRegex.MatchString(label) {
+			return errors.New("labels may only contain a-z, 0-9, - and _")
+		}
+		parsed, err := ParseAddress(addressLeft)
+		if err != nil {
+			return err
+		}
+		address := parsed.Hex()
+		if err := config.AddConfig(bookKeyPrefix+label, address); err != nil {
+			return err
+		}
+		color, _ := GenAddressColor(address)
+		fmt.Fprintln(output.Text, "Label:[", label, "] Address:[", color, "]")
+		return nil
+	},
+}
+
+// AddressBookListCmd represents the utils/checkAddress/book/list command
+var AddressBookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved addresses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/book/list called")
+		book := AddressBook()
+		if output.Structured() {
+			return output.Print(book)
+		}
+		if len(book) == 0 {
+			fmt.Fprintln(output.Text, "The address book is empty, add addresses with utils checkAddress book add")
+			return nil
+		}
+		for _, entry := range book {
+			color, _ := GenAddressColor(entry.Address)
+			fmt.Fprintf(output.Text, "  %-16s %s\n", entry.Label, color)
+		}
+		return nil
+	},
+}
+
+// AddressBookRemoveCmd represents the utils/checkAddress/book/remove command
+var AddressBookRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a saved address",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/book/remove called")
+		return config.DelConfigByKey(bookKeyPrefix + strings.ToLower(bookLabel))
+	},
+}
+
+// AddressScanCmd represents the utils/checkAddress/scan command
+var AddressScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Compare an address with every saved address to detect poisoning",
+	Example: `
+utils checkAddress scan -a 0x..:Check whether the address is a known contact or a lookalike
+utils checkAddress scan -a 0x.. -n 6:Compare the first and last 6 characters`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/scan called")
+		if _, err := ParseAddressPolicy(addressLeft, prompt.PolicyWarn); err != nil {
+			return err
+		}
+		scan := ScanAddress(addressLeft, lookalikeChars)
+		if output.Structured() {
+			return output.Print(scan)
+		}
+		if len(AddressBook()) == 0 {
+			fmt.Fprintln(output.Text, "The address book is empty, add addresses with utils checkAddress book add")
+			return nil
+		}
+		PrintAddressScan(scan)
+		return nil
+	},
+}
+
+// BookEntry is a saved address
+type BookEntry struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+// Lookalike is a saved address sharing the first and last characters of the scanned one
+type Lookalike struct {
+	Label     string `json:"label"`
+	Address   string `json:"address"`
+	Positions []int  `json:"positions"`
+}
+
+// AddressScan is the result of comparing an address with the address book
+type AddressScan struct {
+	Address    string      `json:"address"`
+	Known      string      `json:"known,omitempty"`
+	Lookalikes []Lookalike `json:"lookalikes"`
+}
+
+// Characters compared at both ends of the address, after 0x
+const DefaultLookalikeChars = 4
+
+// Address book keys in the configuration file
+const bookKeyPrefix = "book_"
+
+var bookLabelRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var bookLabel string
+var lookalikeChars int
+
+func init() {
+	// Add command
+	CheckAddressCmd.AddCommand(AddressBookCmd)
+	CheckAddressCmd.AddCommand(AddressScanCmd)
+	AddressBookCmd.AddCommand(AddressBookAddCmd)
+	AddressBookCmd.AddCommand(AddressBookListCmd)
+	AddressBookCmd.AddCommand(AddressBookRemoveCmd)
+
+	// Add flags
+	AddressBookAddCmd.Flags().StringVarP(&bookLabel, "label", "l", "", "label")
+	AddressBookAddCmd.Flags().StringVarP(&addressLeft, "address", "a", "", "address")
+	AddressBookAddCmd.MarkFlagRequired("label")
+	AddressBookAddCmd.MarkFlagRequired("address")
+
+	AddressBookRemoveCmd.Flags().StringVarP(&bookLabel, "label", "l", "", "label")
+	AddressBookRemoveCmd.MarkFlagRequired("label")
+
+	AddressScanCmd.Flags().StringVarP(&addressLeft, "address", "a", "", "address")
+	AddressScanCmd.Flags().IntVarP(&lookalikeChars, "number", "n", DefaultLookalikeChars, "characters compared at the start and the end")
+	AddressScanCmd.MarkFlagRequired("address")
+}
+
+// Every saved address, sorted by label
+func AddressBook() []BookEntry {
+	book := []BookEntry{}
+	for _, key := range viper.AllKeys() {
+		label, ok := strings.CutPrefix(key, bookKeyPrefix)
+		if !ok || !common.IsHexAddress(viper.GetString(key)) {
+			continue
+		}
+		book = append(book, BookEntry{Label: label, Address: common.HexToAddress(viper.GetString(key)).Hex()})
+	}
+	sort.Slice(book, func(i, j int) bool { return book[i].Label < book[j].Label })
+	return book
+}
+
+// Compare an address with the address book, lookalikes share the first and last n characters only
+func ScanAddress(address string, n int) AddressScan {
+	scanned := common.HexToAddress(address).Hex()
+	scan := AddressScan{Address: scanned, Lookalikes: []Lookalike{}}
+
+	body := strings.ToLower(scanned[2:])
+	for _, entry := range AddressBook() {
+		if entry.Address == scanned {
+			scan.Known = entry.Label
+			continue
+		}
+		other := strings.ToLower(entry.Address[2:])
+		if n > 0 && n*2 < len(body) && body[:n] == other[:n] && body[len(body)-n:] == other[len(other)-n:] {
+			_, _, positions := colorDiff(scanned, entry.Address)
+			scan.Lookalikes = append(scan.Lookalikes, Lookalike{Label: entry.Label, Address: entry.Address, Positions: positions})
+		}
+	}
+	return scan
+}
+
+// Print the known contact and highlight the lookalikes
+func PrintAddressScan(scan AddressScan) {
+	if scan.Known != "" {
+		fmt.Fprintln(output.Text, "<-- 📒 Known contact:", scan.Known, "-->")
+	} else if len(scan.Lookalikes) == 0 {
+		fmt.Fprintln(output.Text, "<-- 📒 Not in the address book, no lookalike found -->")
+	}
+
+	for _, lookalike := range scan.Lookalikes {
+		line1, line2, _ := colorDiff(scan.Address, lookalike.Address)
+		fmt.Fprintln(output.Text, "<-- 🚨 Looks like", lookalike.Label, "but differs in the middle, possible address poisoning -->")
+		fmt.Fprintf(output.Text, "  %-16s %s\n", "address", line1)
+		fmt.Fprintf(output.Text, "  %-16s %s\n", lookalike.Label, line2)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	prompt "txtoolbox/cmd/prompt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Test vectors of EIP-55 and EIP-1191, chain 30 is RSK and chain 31 its testnet
+func TestChecksumAddress(t *testing.T) {
+	vectors := []struct {
+		chainID *big.Int
+		want    []string
+	}{
+		{nil, []string{
+			"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+			"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+			"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+		}},
+		{big.NewInt(30), []string{
+			"0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD",
+			"0xFb6916095cA1Df60bb79ce92cE3EA74c37c5d359",
+			"0xDBF03B407c01E7CD3cBea99509D93F8Dddc8C6FB",
+			"0xD1220A0Cf47c7B9BE7a2e6ba89F429762E7B9adB",
+		}},
+		{big.NewInt(31), []string{
+			"0x5aAeb6053F3e94c9b9A09F33669435E7EF1BEaEd",
+			"0xFb6916095CA1dF60bb79CE92ce3Ea74C37c5D359",
+			"0xdbF03B407C01E7cd3cbEa99509D93f8dDDc8C6fB",
+			"0xd1220a0CF47c7B9Be7A2E6Ba89f429762E7b9adB",
+		}},
+	}
+	for _, vector := range vectors {
+		for _, want := range vector.want {
+			if got := ChecksumAddress(common.HexToAddress(want), vector.chainID); got != want {
+				t.Errorf("chain %v: got %s, want %s", vector.chainID, got, want)
+			}
+		}
+	}
+}
+
+func TestChecksumKind(t *testing.T) {
+	eip55 := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	eip1191 := "0x5aaEB6053f3e94c9b9a09f33669435E7ef1bEAeD"
+	vectors := []struct {
+		address string
+		chainID *big.Int
+		want    string
+	}{
+		{strings.ToLower(eip55), nil, "none"},
+		{"0x" + strings.ToUpper(eip55[2:]), nil, "none"},
+		{eip55, nil, "eip55"},
+		{eip55, big.NewInt(30), "eip55"},
+		{eip1191, big.NewInt(30), "eip1191"},
+		{eip1191, nil, "mismatch"},
+		{eip1191, big.NewInt(31), "mismatch"},
+		// One letter changed case
+		{"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil, "mismatch"},
+	}
+	for _, vector := range vectors {
+		if got := ChecksumKind(vector.address, vector.chainID); got != vector.want {
+			t.Errorf("%s on chain %v: got %s, want %s", vector.address, vector.chainID, got, vector.want)
+		}
+	}
+}
+
+func TestParseAddressPolicy(t *testing.T) {
+	bad := "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	if _, err := ParseAddressPolicy(bad, prompt.PolicyFail); !errors.Is(err, ErrChecksum) {
+		t.Errorf("fail policy: got %v, want %v", err, ErrChecksum)
+	}
+	parsed, err := ParseAddressPolicy(bad, prompt.PolicyWarn)
+	if err != nil || parsed != common.HexToAddress(bad) {
+		t.Errorf("warn policy: got %s, %v", parsed.Hex(), err)
+	}
+	for _, invalid := range []string{"", "0x123", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg"} {
+		if _, err := ParseAddressPolicy(invalid, prompt.PolicyWarn); err == nil {
+			t.Errorf("%q was accepted", invalid)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	signer "txtoolbox/cmd/signer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+)
+
+// AllowancesCmd represents the utils/allowances command
+var AllowancesCmd = &cobra.Command{
+	Use:   "allowances",
+	Short: "List the current non-zero approvals of an owner from Approval and ApprovalForAll logs",
+	Example: `
+utils allowances --from-block 19000000:Scan from block 19000000 to the latest block for the signer
+utils allowances --from-block 19000000 --to-block 19100000 --owner 0x..:Scan a fixed range for another owner
+utils allowances --from-block 19000000 --token 0xA0b8..eB48:Only scan one token`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/allowances called")
+		owner, err := allowanceOwner(allowancesOwner)
+		if err != nil {
+			return err
+		}
+		if allowancesChunk == 0 {
+			return errors.New("Check the chunk entered:<0>")
+		}
+		client, _, err := config.DialNetwork()
+		if err != nil {
+			return err
+		}
+
+		toBlock := allowancesToBlock
+		if toBlock == 0 {
+			if toBlock, err = client.BlockNumber(context.Background()); err != nil {
+				return err
+			}
+		}
+		if allowancesFromBlock > toBlock {
+			return fmt.Errorf("the range starts at block %d after its end at block %d", allowancesFromBlock, toBlock)
+		}
+		var tokens []common.Address
+		for _, token := range allowancesTokens {
+			address, err := ParseAddress(token)
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, address)
+		}
+
+		if !output.Structured() {
+			fmt.Fprintf(output.Text, "<-- 🔎 Scanning blocks %d to %d for approvals of %s -->\n", allowancesFromBlock, toBlock, owner.Hex())
+		}
+		logs, err := approvalLogs(client, owner, tokens, allowancesFromBlock, toBlock)
+		if err != nil {
+			return err
+		}
+		allowances := currentAllowances(client, owner, logs)
+
+		if output.Structured() {
+			return output.Print(allowances)
+		}
+		printAllowances(allowances)
+		return nil
+	},
+}
+
+// Allowance is an approval of the owner that is still active
+type Allowance struct {
+	Token    string `json:"token"`
+	Symbol   string `json:"symbol"`
+	Standard string `json:"standard"`
+	Spender  string `json:"spender"`
+	Known    string `json:"known,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Amount   string `json:"amount"`
+	TokenID  string `json:"tokenId,omitempty"`
+	Block    uint64 `json:"block"`
+}
+
+// Approval(owner, spender, value) of ERC-20, Approval(owner, approved, tokenId) of ERC-721
+// and ApprovalForAll(owner, operator, approved) of ERC-721 and ERC-1155
+var (
+	approvalTopic       = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	approvalForAllTopic = crypto.Keccak256Hash([]byte("ApprovalForAll(address,address,bool)"))
+)
+
+// Blocks requested per eth_getLogs call, most providers limit the range
+const DefaultLogChunk = 10000
+
+var allowancesOwner string
+var allowancesFromBlock uint64
+var allowancesToBlock uint64
+var allowancesTokens []string
+var allowancesChunk uint64
+
+func init() {
+	// Add flags
+	AllowancesCmd.Flags().StringVar(&allowancesOwner, "owner", "", "owner of the approvals, defaults to the configured signer")
+	AllowancesCmd.Flags().Uint64Var(&allowancesFromBlock, "from-block", 0, "first block to scan")
+	AllowancesCmd.Flags().Uint64Var(&allowancesToBlock, "to-block", 0, "last block to scan, defaults to the latest block")
+	AllowancesCmd.Flags().StringSliceVar(&allowancesTokens, "token", nil, "only scan these token contracts")
+	AllowancesCmd.Flags().Uint64Var(&allowancesChunk, "chunk", DefaultLogChunk, "blocks requested per eth_getLogs call")
+	AllowancesCmd.MarkFlagRequired("from-block")
+}
+
+// The owner from the flag or the configured signer
+func allowanceOwner(address string) (common.Address, error) {
+	if address != "" {
+		return ParseAddress(address)
+	}
+	if configured, ok := signer.ConfiguredAddress(); ok {
+		return configured, nil
+	}
+	return common.Address{}, errors.New("please enter a valid owner address with --owner")
+}
+
+// Fetch the approval logs of the owner in chunks of blocks
+func approvalLogs(client *ethclient.Client, owner common.Address, tokens []common.Address, from, to uint64) ([]types.Log, error) {
+	var logs []types.Log
+	for start := from; start <= to; start += allowancesChunk {
+		end := min(start+allowancesChunk-1, to)
+		query := ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: tokens,
+			Topics:    [][]common.Hash{{approvalTopic, approvalForAllTopic}, {common.BytesToHash(owner.Bytes())}},
+		}
+		chunk, err := client.FilterLogs(context.Background(), query)
+		if err != nil {
+			return nil, fmt.Errorf("blocks %d to %d: %v", start, end, err)
+		}
+		logs = append(logs, chunk...)
+		// Stop before the unsigned block number wraps around
+		if end == to {
+			break
+		}
+	}
+	return logs, nil
+}
+
+// Deduplicate the logs and keep the approvals that are still active on the latest block.
+// A contract that emits approval events without the full interface is skipped with a warning
+func currentAllowances(client *ethclient.Client, owner common.Address, logs []types.Log) []Allowance {
+	type approvalKey struct {
+		token   common.Address
+		topic   common.Hash
+		subject common.Hash
+	}
+	var keys []approvalKey
+	latest := map[approvalKey]types.Log{}
+	for _, log := range logs {
+		if log.Removed || len(log.Topics) < 3 {
+			continue
+		}
+		// ERC-721 approvals are per token ID, the others per spender
+		key := approvalKey{token: log.Address, topic: log.Topics[0], subject: log.Topics[2]}
+		if log.Topics[0] == approvalTopic && len(log.Topics) == 4 {
+			key.subject = log.Topics[3]
+		}
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = log
+	}
+
+	allowances := []Allowance{}
+	decimals := map[common.Address]*uint8{}
+	symbols := map[common.Address]string{}
+	skipped := map[common.Address]bool{}
+	skip := func(token common.Address, err error) {
+		if !skipped[token] {
+			fmt.Fprintln(output.Text, "<-- ⚠️  Skipping", token.Hex()+":", err, "-->")
+		}
+		skipped[token] = true
+	}
+	for _, key := range keys {
+		log := latest[key]
+		if skipped[log.Address] {
+			continue
+		}
+		if _, ok := symbols[log.Address]; !ok {
+			symbols[log.Address] = ReadSymbol(client, log.Address)
+		}
+		allowance := Allowance{Token: log.Address.Hex(), Symbol: symbols[log.Address], Block: log.BlockNumber}
+
+		switch {
+		case key.topic == approvalForAllTopic:
+			operator := common.BytesToAddress(log.Topics[2].Bytes())
+			approved, err := CallMethod(client, log.Address, IsApprovedForAll, owner, operator)
+			if err != nil {
+				skip(log.Address, fmt.Errorf("isApprovedForAll(): %v", err))
+				continue
+			}
+			if !approved[0].(bool) {
+				continue
+			}
+			allowance.Standard = OperatorStandard(client, log.Address)
+			allowance.Spender = operator.Hex()
+			allowance.Amount = "all tokens"
+
+		case len(log.Topics) == 4:
+			tokenID := log.Topics[3].Big()
+			// A transferred token no longer carries the approval of the owner
+			holder, err := CallMethod(client, log.Address, ERC721OwnerOf, tokenID)
+			if err != nil || holder[0].(common.Address) != owner {
+				continue
+			}
+			approved, err := CallMethod(client, log.Address, ERC721GetApproved, tokenID)
+			if err != nil {
+				skip(log.Address, fmt.Errorf("getApproved(): %v", err))
+				continue
+			}
+			if approved[0].(common.Address) == (common.Address{}) {
+				continue
+			}
+			allowance.Standard = "erc721"
+			allowance.Spender = approved[0].(common.Address).Hex()
+			allowance.TokenID = tokenID.String()
+			allowance.Amount = "token " + tokenID.String()
+
+		default:
+			spender := common.BytesToAddress(log.Topics[2].Bytes())
+			value, err := CallMethod(client, log.Address, ERC20Allowance, owner, spender)
+			if err != nil {
+				skip(log.Address, fmt.Errorf("allowance(): %v", err))
+				continue
+			}
+			remaining := value[0].(*big.Int)
+			if remaining.Sign() == 0 {
+				continue
+			}
+			if _, ok := decimals[log.Address]; !ok {
+				decimals[log.Address] = nil
+				if result, err := CallMethod(client, log.Address, ERC20Decimals); err == nil {
+					value := result[0].(uint8)
+					decimals[log.Address] = &value
+				}
+			}
+			allowance.Standard = "erc20"
+			allowance.Spender = spender.Hex()
+			allowance.Value = remaining.String()
+			// Without decimals() the amount is only known in the smallest unit
+			if tokenDecimals := decimals[log.Address]; tokenDecimals != nil {
+				allowance.Amount = FormatAllowance(remaining, *tokenDecimals, symbols[log.Address])
+			} else {
+				allowance.Amount = remaining.String() + " (raw, decimals() is missing)"
+			}
+		}
+		allowance.Known = ScanAddress(allowance.Spender, DefaultLookalikeChars).Known
+		allowances = append(allowances, allowance)
+	}
+	return allowances
+}
+
+// Print every active approval with the command that revokes it
+func printAllowances(allowances []Allowance) {
+	if len(allowances) == 0 {
+		fmt.Fprintln(output.Text, "<-- ✅ No active approval found in the range -->")
+		return
+	}
+	fmt.Fprintln(output.Text, "╔═══════════════[ 🔐 Active approvals ]═══════════════╗")
+	for i, allowance := range allowances {
+		if i > 0 {
+			fmt.Fprintln(output.Text)
+		}
+		spenderColor, _ := GenAddressColor(allowance.Spender)
+		if allowance.Known != "" {
+			spenderColor += " (" + allowance.Known + ")"
+		}
+		fmt.Fprintf(output.Text, "  %-8s: %s %s (%s)\n", "token", allowance.Token, allowance.Symbol, allowance.Standard)
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "spender", spenderColor)
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "amount", allowance.Amount)
+		fmt.Fprintf(output.Text, "  %-8s: %d\n", "block", allowance.Block)
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "revoke", revokeCommand(allowance))
+	}
+	fmt.Fprintln(output.Text, "╚═════════════════════════════════════════════════════╝")
+}
+
+// The trade revoke command for an approval
+func revokeCommand(allowance Allowance) string {
+	switch {
+	case allowance.TokenID != "":
+		return "trade revoke --token " + allowance.Token + " --token-id " + allowance.TokenID
+	case allowance.Standard == "erc20":
+		return "trade revoke --token " + allowance.Token + " --spender " + allowance.Spender
+	default:
+		return "trade revoke --token " + allowance.Token + " --spender " + allowance.Spender + " --all"
+	}
+}
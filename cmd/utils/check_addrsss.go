@@ -22,9 +22,10 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"hash/fnv"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/spf13/cobra"
@@ -36,7 +37,10 @@ var CheckAddressCmd = &cobra.Command{
 	Short: "Add unique colors to addresses and check for differences",
 	Long:  figure.NewFigure("checkAddrsss", "", true).String(),
 	Example: `	
-utils checkAddress color -a:Add a unique color to the address`,
+utils checkAddress color -a:Add a unique color to the address
+utils checkAddress checksum -a:Check the EIP-55 and EIP-1191 checksums
+utils checkAddress scan -a:Compare the address with the address book
+utils checkAddress book -h:Save known addresses`,
 }
 
 // Add a unique color to the address
@@ -46,12 +50,15 @@ var addressColorCmd = &cobra.Command{
 	Example: `
 utils color -a:Add a unique color to the address`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("utils/color called")
+		fmt.Fprintln(output.Text, "utils/color called")
 		color, err := GenAddressColor(addressLeft)
 		if err != nil {
 			return err
 		}
-		fmt.Println(color)
+		if output.Structured() {
+			return output.Print(AddressColor{Address: addressLeft, Colors: addressRGB(addressLeft)})
+		}
+		fmt.Fprintln(output.Text, color)
 
 		return nil
 	},
@@ -64,7 +71,7 @@ var colorDiffCmd = &cobra.Command{
 	Example: `
 utils diff -l -r:Compare two colors and color different characters`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("utils/diff called")
+		fmt.Fprintln(output.Text, "utils/diff called")
 		err := addrssCheckDiffCmd(addressLeft, addressRight)
 		return err
 	},
@@ -73,6 +80,20 @@ utils diff -l -r:Compare two colors and color different characters`,
 var addressLeft string
 var addressRight string
 
+// AddressColor is the structured output of color, one #rrggbb color per character
+type AddressColor struct {
+	Address string   `json:"address"`
+	Colors  []string `json:"colors"`
+}
+
+// AddressDiff is the structured output of diff, positions are the differing character indexes
+type AddressDiff struct {
+	Left       string `json:"left"`
+	Right      string `json:"right"`
+	Difference bool   `json:"difference"`
+	Positions  []int  `json:"positions"`
+}
+
 func init() {
 	// Add command
 	CheckAddressCmd.AddCommand(addressColorCmd)
@@ -90,25 +111,41 @@ func init() {
 
 // Use the FNV hash function to generate colors
 func charToColor(char byte) string {
+	r, g, b := charToRGB(char)
+
+	// ANSI 256 color format "\033[38;2;R;G;Bm"
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+}
+
+// The RGB components of the color of a character
+func charToRGB(char byte) (r, g, b uint64) {
 	// Make the colors richer with New64a
 	h := fnv.New64a()
 	h.Write([]byte{char})
 	hash := h.Sum64()
 
 	// Convert the hash to a color
-	r := (hash & 0xFF0000) >> 16
-	g := (hash & 0x00FF00) >> 8
-	b := hash & 0x0000FF
+	r = (hash & 0xFF0000) >> 16
+	g = (hash & 0x00FF00) >> 8
+	b = hash & 0x0000FF
+	return r, g, b
+}
 
-	// ANSI 256 color format "\033[38;2;R;G;Bm"
-	return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+// The colors of every character of the address as #rrggbb
+func addressRGB(address string) []string {
+	colors := make([]string, len(address))
+	for i := 0; i < len(address); i++ {
+		r, g, b := charToRGB(address[i])
+		colors[i] = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return colors
 }
 
 // Generates a unique color for the input address
 func GenAddressColor(address string) (string, error) {
 	// Check if the address is valid
-	if len(address) != 42 {
-		return "", errors.New("please enter a valid address")
+	if _, err := ParseAddressPolicy(address, prompt.PolicyWarn); err != nil {
+		return "", err
 	}
 
 	var result string
@@ -122,30 +159,43 @@ func GenAddressColor(address string) (string, error) {
 
 // Compare two colors and color different characters
 func addrssCheckDiffCmd(addressL, addressR string) error {
-	if len(addressL) != 42 || len(addressR) != 42 {
-		return errors.New("please enter a valid address")
+	if _, err := ParseAddressPolicy(addressL, prompt.PolicyWarn); err != nil {
+		return err
+	}
+	if _, err := ParseAddressPolicy(addressR, prompt.PolicyWarn); err != nil {
+		return err
 	}
 
+	line1, line2, positions := colorDiff(addressL, addressR)
+	difference := len(positions) > 0
+	if output.Structured() {
+		return output.Print(AddressDiff{Left: addressL, Right: addressR, Difference: difference, Positions: positions})
+	}
+	fmt.Fprintln(output.Text, "Left address -> ", line1)
+	fmt.Fprintln(output.Text, "Right address -> ", line2)
+	fmt.Fprintln(output.Text, "Difference -> ", difference)
+	return nil
+}
+
+// Color the characters that differ between two addresses of the same length
+func colorDiff(addressL, addressR string) (string, string, []int) {
 	var line1, line2 string
-	var difference bool
+	positions := []int{}
 	for i := 0; i < len(addressL); i++ {
 		char1 := addressL[i]
 		char2 := addressR[i]
 		if char1 != char2 {
+			positions = append(positions, i)
 			color1 := charToColor(char1)
 			color2 := charToColor(char2)
 
 			line1 += fmt.Sprintf("%s%c\033[0m", color1, char1)
 			line2 += fmt.Sprintf("%s%c\033[0m", color2, char2)
-			difference = true
 
 		} else {
 			line1 += fmt.Sprintf("%c", char1)
 			line2 += fmt.Sprintf("%c", char2)
 		}
 	}
-	fmt.Println("Left address -> ", line1)
-	fmt.Println("Right address -> ", line2)
-	fmt.Println("Difference -> ", difference)
-	return nil
+	return line1, line2, positions
 }
@@ -0,0 +1,378 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	output "txtoolbox/cmd/output"
+	signer "txtoolbox/cmd/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// AddressCmd represents the utils/address command
+var AddressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Compute the address of a contract before it is deployed",
+	Example: `
+utils address create -d 0x.. -n 7:Address of a CREATE from the deployer at nonce 7
+utils address create2 --factory safe -s 0x.. --init-code Token.bin:Address of a CREATE2 through the Safe singleton factory
+utils address create2 --factory createx --create3 -s 0x..:Address of a CREATE3 through CreateX`,
+}
+
+// AddressCreateCmd represents the utils/address/create command
+var AddressCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Compute CREATE addresses from the deployer and its nonce",
+	Example: `
+utils address create -d 0x.. -n 0:Address of the first contract of the deployer
+utils address create -n 0 --count 5:Addresses of the next 5 contracts of the configured account`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/address/create called")
+		deployer, err := deployerAddress(createDeployer)
+		if err != nil {
+			return err
+		}
+		if createCount == 0 {
+			return errors.New("Check the count entered:<0>")
+		}
+
+		var addresses []DeploymentAddress
+		for i := uint64(0); i < createCount; i++ {
+			nonce := createNonce + i
+			addresses = append(addresses, DeploymentAddress{
+				Scheme:   "create",
+				Deployer: deployer.Hex(),
+				Nonce:    &nonce,
+				Address:  crypto.CreateAddress(deployer, nonce).Hex(),
+			})
+		}
+
+		if output.Structured() {
+			return output.Print(addresses)
+		}
+		fmt.Fprintln(output.Text, "╔═══════════[ 🏗️  CREATE addresses ]═══════════╗")
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "deployer", deployer.Hex())
+		for _, address := range addresses {
+			addressColor, _ := GenAddressColor(address.Address)
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", fmt.Sprint("nonce ", *address.Nonce), addressColor)
+		}
+		fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+		return nil
+	},
+}
+
+// AddressCreate2Cmd represents the utils/address/create2 command
+var AddressCreate2Cmd = &cobra.Command{
+	Use:   "create2 [constructor args...]",
+	Short: "Compute CREATE2 and CreateX CREATE3 addresses from the deployer, salt and init code",
+	Example: `
+utils address create2 -d 0x.. -s 0x.. --init-code-hash 0x..:Address from the init code hash
+utils address create2 --factory arachnid -s 1 --init-code 0x6080..:Through the deterministic deployment proxy
+utils address create2 --factory createx -s 0x.. -a Token.json "My Token" 100:Through CreateX with constructor arguments
+utils address create2 --factory createx --create3 -s 0x.. --sender 0x..:CREATE3 through CreateX, no init code needed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/address/create2 called")
+		result, err := create2Address(args)
+		if err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			return output.Print(result)
+		}
+		addressColor, _ := GenAddressColor(result.Address)
+		fmt.Fprintf(output.Text, "╔═══════════[ 🏗️  %s address ]═══════════╗\n", strings.ToUpper(result.Scheme))
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "deployer", result.Deployer)
+		if result.Factory != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "factory", result.Factory)
+		}
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "salt", result.Salt)
+		if result.GuardedSalt != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "guarded", result.GuardedSalt)
+		}
+		if result.InitCodeHash != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "initHash", result.InitCodeHash)
+		}
+		if result.Proxy != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "proxy", result.Proxy)
+		}
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "address", addressColor)
+		fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+		return nil
+	},
+}
+
+// DeploymentAddress is the structured output of create and create2
+type DeploymentAddress struct {
+	Scheme       string  `json:"scheme"`
+	Deployer     string  `json:"deployer"`
+	Factory      string  `json:"factory,omitempty"`
+	Nonce        *uint64 `json:"nonce,omitempty"`
+	Salt         string  `json:"salt,omitempty"`
+	GuardedSalt  string  `json:"guardedSalt,omitempty"`
+	InitCodeHash string  `json:"initCodeHash,omitempty"`
+	Proxy        string  `json:"proxy,omitempty"`
+	Address      string  `json:"address"`
+}
+
+// Factories deployed at the same address on most chains
+var Create2Factories = map[string]common.Address{
+	// Deterministic deployment proxy, the salt is the first 32 bytes of the calldata
+	"arachnid": common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C"),
+	// Safe singleton factory, same calling convention as the deterministic deployment proxy
+	"safe": common.HexToAddress("0x914d7Fec6aaC8cd542e72Bca78B30650d45643d7"),
+	// CreateX, the salt is guarded with the sender and the chain before use
+	"createx": common.HexToAddress("0xba5Ed099633D3B313e4D5F7bdc1305d3c28ba5Ed"),
+}
+
+// Init code of the proxy CreateX deploys with CREATE2 before the CREATE3 contract
+var createXProxyInitCode = common.FromHex("0x67363d3d37363d34f03d5260086018f3")
+
+var createDeployer string
+var createNonce uint64
+var createCount uint64
+var create2Factory string
+var create2Salt string
+var create2InitCode string
+var create2InitCodeHash string
+var create2Artifact string
+var create2Sender string
+var create2ChainID string
+var create2Create3 bool
+
+func init() {
+	// Add command
+	AddressCmd.AddCommand(AddressCreateCmd)
+	AddressCmd.AddCommand(AddressCreate2Cmd)
+
+	// Add flags
+	AddressCreateCmd.Flags().StringVarP(&createDeployer, "deployer", "d", "", "deployer address (default is the configured account)")
+	AddressCreateCmd.Flags().Uint64VarP(&createNonce, "nonce", "n", 0, "nonce of the deployment transaction")
+	AddressCreateCmd.Flags().Uint64Var(&createCount, "count", 1, "number of consecutive nonces to compute")
+	AddressCreateCmd.MarkFlagRequired("nonce")
+
+	AddressCreate2Cmd.Flags().StringVarP(&createDeployer, "deployer", "d", "", "contract executing CREATE2")
+	AddressCreate2Cmd.Flags().StringVar(&create2Factory, "factory", "", "well known factory: arachnid/safe/createx")
+	AddressCreate2Cmd.Flags().StringVarP(&create2Salt, "salt", "s", "", "salt as 32 bytes of hex or a decimal number")
+	AddressCreate2Cmd.Flags().StringVar(&create2InitCode, "init-code", "", "init code as hex or a file holding it")
+	AddressCreate2Cmd.Flags().StringVar(&create2InitCodeHash, "init-code-hash", "", "keccak256 hash of the init code")
+	AddressCreate2Cmd.Flags().StringVarP(&create2Artifact, "artifact", "a", "", "Foundry or Hardhat artifact, constructor arguments follow")
+	AddressCreate2Cmd.Flags().StringVar(&create2Sender, "sender", "", "account calling CreateX (default is the configured account)")
+	AddressCreate2Cmd.Flags().StringVar(&create2ChainID, "chain-id", "", "chain ID for CreateX salts with cross-chain protection (default is the chainId key)")
+	AddressCreate2Cmd.Flags().BoolVar(&create2Create3, "create3", false, "compute the CreateX CREATE3 address, which does not depend on the init code")
+	AddressCreate2Cmd.MarkFlagRequired("salt")
+	AddressCreate2Cmd.MarkFlagsMutuallyExclusive("deployer", "factory")
+	AddressCreate2Cmd.MarkFlagsMutuallyExclusive("init-code", "init-code-hash", "artifact")
+}
+
+// The deployer from the flag, or the configured account
+func deployerAddress(address string) (common.Address, error) {
+	if address != "" {
+		return ParseAddress(address)
+	}
+	if configured, ok := signer.ConfiguredAddress(); ok {
+		return configured, nil
+	}
+	return common.Address{}, errors.New("please enter a valid deployer address with --deployer")
+}
+
+// Compute the CREATE2 or CREATE3 address from the flags
+func create2Address(args []string) (DeploymentAddress, error) {
+	result := DeploymentAddress{Scheme: "create2"}
+
+	// A factory fixes the deployer
+	var deployer common.Address
+	if create2Factory != "" {
+		factory, ok := Create2Factories[create2Factory]
+		if !ok {
+			return result, errors.New("Check the factory entered:<" + create2Factory + ">, use arachnid/safe/createx")
+		}
+		deployer = factory
+		result.Factory = create2Factory
+	} else if createDeployer != "" {
+		var err error
+		if deployer, err = ParseAddress(createDeployer); err != nil {
+			return result, err
+		}
+	} else {
+		return result, errors.New("please enter a --deployer or a --factory")
+	}
+	result.Deployer = deployer.Hex()
+
+	salt, err := parseSalt(create2Salt)
+	if err != nil {
+		return result, err
+	}
+	result.Salt = hexutil.Encode(salt[:])
+
+	// CreateX hashes the salt before use
+	if create2Factory == "createx" {
+		guarded, err := createXGuardedSalt(salt)
+		if err != nil {
+			return result, err
+		}
+		salt = guarded
+		result.GuardedSalt = hexutil.Encode(salt[:])
+	}
+
+	// CREATE3 deploys a proxy with CREATE2, which deploys the contract with its first CREATE
+	if create2Create3 {
+		if create2Factory != "createx" {
+			return result, errors.New("--create3 is only supported with --factory createx")
+		}
+		proxy := crypto.CreateAddress2(deployer, salt, crypto.Keccak256(createXProxyInitCode))
+		result.Scheme = "create3"
+		result.Proxy = proxy.Hex()
+		result.Address = crypto.CreateAddress(proxy, 1).Hex()
+		return result, nil
+	}
+
+	initCodeHash, err := create2InitCodeHashOf(args)
+	if err != nil {
+		return result, err
+	}
+	result.InitCodeHash = hexutil.Encode(initCodeHash)
+	result.Address = crypto.CreateAddress2(deployer, salt, initCodeHash).Hex()
+	return result, nil
+}
+
+// The init code hash from --init-code-hash, --init-code, or --artifact and its constructor arguments
+func create2InitCodeHashOf(args []string) ([]byte, error) {
+	switch {
+	case create2InitCodeHash != "":
+		initCodeHash, err := hexutil.Decode(create2InitCodeHash)
+		if err != nil || len(initCodeHash) != 32 {
+			return nil, errors.New("please enter a valid 32 byte --init-code-hash")
+		}
+		return initCodeHash, nil
+	case create2InitCode != "":
+		initCode, err := ReadBytecode(create2InitCode)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(initCode), nil
+	case create2Artifact != "":
+		contractAbi, code, err := LoadArtifact(create2Artifact)
+		if err != nil {
+			return nil, err
+		}
+		inputs := contractAbi.Constructor.Inputs
+		if len(args) != len(inputs) {
+			return nil, fmt.Errorf("the constructor takes %d arguments, got %d", len(inputs), len(args))
+		}
+		values, err := ParseArgValues(inputs, args)
+		if err != nil {
+			return nil, err
+		}
+		packed, err := inputs.Pack(values...)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(code, packed), nil
+	default:
+		return nil, errors.New("please enter --init-code, --init-code-hash or --artifact")
+	}
+}
+
+// Parse a salt given as 32 bytes of hex or as a decimal number
+func parseSalt(input string) ([32]byte, error) {
+	var salt [32]byte
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "0x") {
+		decoded, err := hexutil.Decode(input)
+		if err != nil || len(decoded) != 32 {
+			return salt, errors.New("Check the salt entered:<" + input + ">, it must be 32 bytes of hex")
+		}
+		copy(salt[:], decoded)
+		return salt, nil
+	}
+	number, ok := new(big.Int).SetString(input, 10)
+	if !ok || number.Sign() < 0 || number.BitLen() > 256 {
+		return salt, errors.New("Check the salt entered:<" + input + ">, it must be 32 bytes of hex or a decimal number")
+	}
+	number.FillBytes(salt[:])
+	return salt, nil
+}
+
+// Apply the _guard of CreateX, which binds the salt to the sender and the chain
+// according to its first 20 bytes and its 21st byte
+func createXGuardedSalt(salt [32]byte) ([32]byte, error) {
+	saltSender := common.BytesToAddress(salt[:20])
+	protected := salt[20]
+
+	// The sender only matters when the salt starts with an address
+	var sender common.Address
+	if saltSender != (common.Address{}) {
+		var err error
+		if sender, err = deployerAddress(create2Sender); err != nil {
+			return salt, errors.New("the salt starts with an address, enter the account calling CreateX with --sender")
+		}
+	}
+
+	switch {
+	case saltSender == sender && saltSender != (common.Address{}):
+		switch protected {
+		case 0x01:
+			chainID, err := create2ChainIDOf()
+			if err != nil {
+				return salt, err
+			}
+			return crypto.Keccak256Hash(common.LeftPadBytes(sender.Bytes(), 32), common.LeftPadBytes(chainID.Bytes(), 32), salt[:]), nil
+		case 0x00:
+			return crypto.Keccak256Hash(common.LeftPadBytes(sender.Bytes(), 32), salt[:]), nil
+		}
+		return salt, errors.New("CreateX rejects salts whose 21st byte is not 00 or 01")
+	case saltSender == (common.Address{}) && protected == 0x01:
+		chainID, err := create2ChainIDOf()
+		if err != nil {
+			return salt, err
+		}
+		return crypto.Keccak256Hash(common.LeftPadBytes(chainID.Bytes(), 32), salt[:]), nil
+	case saltSender == (common.Address{}) && protected > 0x01:
+		return salt, errors.New("CreateX rejects salts whose 21st byte is not 00 or 01")
+	default:
+		// Salts without a guard are hashed so they cannot bypass the guarded cases
+		return crypto.Keccak256Hash(salt[:]), nil
+	}
+}
+
+// The chain ID of salts with cross-chain redeploy protection
+func create2ChainIDOf() (*big.Int, error) {
+	if create2ChainID != "" {
+		chainID, ok := new(big.Int).SetString(create2ChainID, 10)
+		if !ok {
+			return nil, errors.New("Check the chain ID entered:<" + create2ChainID + ">")
+		}
+		return chainID, nil
+	}
+	if chainID := configuredChainID(); chainID != nil {
+		return chainID, nil
+	}
+	return nil, errors.New("the salt protects against cross-chain redeploys, enter the chain ID with --chain-id")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Every branch of the CreateX _guard, the expected salts are built with abi.encode as CreateX does
+func TestCreateXGuardedSalt(t *testing.T) {
+	sender := common.HexToAddress("0x14791697260E4c9A71f18484C9f997B308e59325")
+	other := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	chainID := big.NewInt(10)
+	create2Sender, create2ChainID = sender.Hex(), chainID.String()
+	defer func() { create2Sender, create2ChainID = "", "" }()
+
+	salt := func(prefix common.Address, flag byte) [32]byte {
+		var s [32]byte
+		copy(s[:20], prefix.Bytes())
+		s[20] = flag
+		copy(s[21:], bytes.Repeat([]byte{0xab}, 11))
+		return s
+	}
+	encode := func(t *testing.T, types []string, values ...any) common.Hash {
+		var arguments abi.Arguments
+		for _, name := range types {
+			typ, err := abi.NewType(name, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			arguments = append(arguments, abi.Argument{Type: typ})
+		}
+		packed, err := arguments.Pack(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return crypto.Keccak256Hash(packed)
+	}
+
+	vectors := []struct {
+		name  string
+		salt  [32]byte
+		want  func(t *testing.T, s [32]byte) common.Hash
+		fails bool
+	}{
+		{"sender and cross-chain protection", salt(sender, 0x01), func(t *testing.T, s [32]byte) common.Hash {
+			return encode(t, []string{"address", "uint256", "bytes32"}, sender, chainID, s)
+		}, false},
+		{"sender protection only", salt(sender, 0x00), func(t *testing.T, s [32]byte) common.Hash {
+			return encode(t, []string{"bytes32", "bytes32"}, common.BytesToHash(sender.Bytes()), s)
+		}, false},
+		{"sender with an unspecified flag", salt(sender, 0x02), nil, true},
+		{"cross-chain protection only", salt(common.Address{}, 0x01), func(t *testing.T, s [32]byte) common.Hash {
+			return encode(t, []string{"uint256", "bytes32"}, chainID, s)
+		}, false},
+		{"zero address with an unspecified flag", salt(common.Address{}, 0x02), nil, true},
+		{"zero address without protection", salt(common.Address{}, 0x00), func(t *testing.T, s [32]byte) common.Hash {
+			return encode(t, []string{"bytes32"}, s)
+		}, false},
+		{"another address ignores the flag", salt(other, 0x01), func(t *testing.T, s [32]byte) common.Hash {
+			return encode(t, []string{"bytes32"}, s)
+		}, false},
+	}
+	for _, vector := range vectors {
+		got, err := createXGuardedSalt(vector.salt)
+		if vector.fails {
+			if err == nil {
+				t.Errorf("%s: the salt was accepted", vector.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", vector.name, err)
+			continue
+		}
+		if want := vector.want(t, vector.salt); got != want {
+			t.Errorf("%s: got %x, want %x", vector.name, got, want)
+		}
+	}
+}
+
+// A salt starting with an address needs the sender, the chain is only needed with protection
+func TestCreateXGuardedSaltNeedsContext(t *testing.T) {
+	create2Sender, create2ChainID = "", ""
+	var s [32]byte
+	copy(s[:20], common.HexToAddress("0x14791697260E4c9A71f18484C9f997B308e59325").Bytes())
+	if _, err := createXGuardedSalt(s); err == nil {
+		t.Error("a salt starting with an address was guarded without --sender")
+	}
+
+	s = [32]byte{}
+	s[20] = 0x01
+	if _, err := createXGuardedSalt(s); err == nil {
+		t.Error("a cross-chain protected salt was guarded without the chain ID")
+	}
+}
+
+// The proxy CreateX deploys for CREATE3, as published by CreateX
+func TestCreateXProxyInitCodeHash(t *testing.T) {
+	want := common.HexToHash("0x21c35dbe1b344a2488cf3321d6ce542f8e9f305544ff09e4993a62319a497c1f")
+	if got := crypto.Keccak256Hash(createXProxyInitCode); got != want {
+		t.Errorf("got %s, want %s", got.Hex(), want.Hex())
+	}
+}
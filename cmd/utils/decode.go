@@ -0,0 +1,267 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	output "txtoolbox/cmd/output"
+
+	"github.com/common-nighthawk/go-figure"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// DecodeCmd represents the utils/decode command
+var DecodeCmd = &cobra.Command{
+	Use:   "decode",
+	Short: "Decode calldata and raw transactions",
+	Long:  figure.NewFigure("Decode", "", true).String(),
+	Example: `
+utils decode calldata 0xa9059cbb..:Decode calldata
+utils decode tx 0x02f8..:Decode a raw transaction`,
+}
+
+// DecodeCalldataCmd represents the utils/decode/calldata command
+var DecodeCalldataCmd = &cobra.Command{
+	Use:   "calldata <hex>",
+	Short: "Decode calldata with the bundled signatures and ABI files",
+	Example: `
+utils decode calldata 0xa9059cbb..:Match the selector against the bundled signatures
+utils decode calldata 0x.. --abi router.json --abi vault.json:Also search ABI files`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/decode/calldata called")
+		data, err := hexutil.Decode(strings.TrimSpace(args[0]))
+		if err != nil {
+			return errors.New("Check the calldata entered:<" + args[0] + ">")
+		}
+
+		calls, err := DecodeCalldata(data, decodeABIs)
+		if err != nil {
+			return err
+		}
+		if output.Structured() {
+			return output.Print(calls)
+		}
+		PrintDecodedCalls(calls)
+		return nil
+	},
+}
+
+// DecodedCall is a function matching the selector of calldata
+type DecodedCall struct {
+	Signature string       `json:"signature"`
+	Selector  string       `json:"selector"`
+	Source    string       `json:"source"`
+	Arguments []DecodedArg `json:"arguments"`
+}
+
+// DecodedArg is a decoded argument, tuples hold []DecodedArg and arrays []any
+type DecodedArg struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// Source name of the bundled signatures
+const bundledSource = "bundled"
+
+//go:embed signatures.txt
+var bundledSignatures string
+
+var bundledOnce sync.Once
+var bundledMethods []abi.Method
+
+var decodeABIs []string
+
+func init() {
+	// Add command
+	DecodeCmd.AddCommand(DecodeCalldataCmd)
+
+	// Add flags
+	DecodeCalldataCmd.Flags().StringArrayVar(&decodeABIs, "abi", nil, "ABI JSON file or compiled artifact, can be repeated")
+}
+
+// Match the selector of calldata against ABI files, then the bundled signatures
+func DecodeCalldata(data []byte, abiPaths []string) ([]DecodedCall, error) {
+	if len(data) < 4 {
+		return nil, errors.New("calldata is shorter than a 4 byte selector")
+	}
+
+	type candidate struct {
+		method abi.Method
+		source string
+	}
+	var candidates []candidate
+	for _, path := range abiPaths {
+		contractAbi, err := LoadABI(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, method := range contractAbi.Methods {
+			candidates = append(candidates, candidate{method, path})
+		}
+	}
+	for _, method := range loadBundledMethods() {
+		candidates = append(candidates, candidate{method, bundledSource})
+	}
+
+	// Exact encodings win over ones with trailing or malformed data
+	var exact, loose []DecodedCall
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if !bytes.Equal(c.method.ID, data[:4]) || seen[c.method.Sig] {
+			continue
+		}
+		values, err := c.method.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		seen[c.method.Sig] = true
+
+		call := DecodedCall{
+			Signature: c.method.Sig,
+			Selector:  hexutil.Encode(data[:4]),
+			Source:    c.source,
+			Arguments: DecodeArgs(c.method.Inputs, values),
+		}
+		if packed, err := c.method.Inputs.Pack(values...); err == nil && bytes.Equal(packed, data[4:]) {
+			exact = append(exact, call)
+		} else {
+			loose = append(loose, call)
+		}
+	}
+
+	calls := append(exact, loose...)
+	if len(calls) == 0 {
+		return nil, errors.New("unknown selector " + hexutil.Encode(data[:4]) + ", add the ABI with --abi")
+	}
+	return calls, nil
+}
+
+// Parse the bundled signatures once
+func loadBundledMethods() []abi.Method {
+	bundledOnce.Do(func() {
+		scanner := bufio.NewScanner(strings.NewReader(bundledSignatures))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			method, err := ParseMethodSignature(line)
+			if err != nil {
+				panic("invalid bundled signature " + line + ": " + err.Error())
+			}
+			bundledMethods = append(bundledMethods, method)
+		}
+	})
+	return bundledMethods
+}
+
+// Pair decoded values with their names and types
+func DecodeArgs(arguments abi.Arguments, values []any) []DecodedArg {
+	args := make([]DecodedArg, 0, len(values))
+	for i, argument := range arguments {
+		if i >= len(values) {
+			break
+		}
+		args = append(args, DecodedArg{
+			Name:  argument.Name,
+			Type:  argument.Type.String(),
+			Value: decodeValue(argument.Type, reflect.ValueOf(values[i])),
+		})
+	}
+	return args
+}
+
+// Expand tuples and arrays, other values are formatted as text
+func decodeValue(t abi.Type, v reflect.Value) any {
+	for v.Kind() == reflect.Ptr && t.T == abi.TupleTy {
+		v = v.Elem()
+	}
+
+	switch t.T {
+	case abi.TupleTy:
+		args := make([]DecodedArg, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			args[i] = DecodedArg{
+				Name:  t.TupleRawNames[i],
+				Type:  elem.String(),
+				Value: decodeValue(*elem, v.Field(i)),
+			}
+		}
+		return args
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = decodeValue(*t.Elem, v.Index(i))
+		}
+		return items
+	default:
+		return FormatABIValue(v.Interface())
+	}
+}
+
+// Print every matching function with its arguments
+func PrintDecodedCalls(calls []DecodedCall) {
+	if len(calls) > 1 {
+		fmt.Fprintln(output.Text, "<-- ⚠️ ", len(calls), "functions share this selector, the first one encodes the data exactly -->")
+	}
+	for _, call := range calls {
+		fmt.Fprintln(output.Text, "<-- 🔍", call.Signature, call.Selector, "from", call.Source, "-->")
+		for i, arg := range call.Arguments {
+			printDecodedValue("  ", fmt.Sprintf("[%d]", i), arg)
+		}
+	}
+}
+
+// Print a value, nesting tuples and arrays with indentation
+func printDecodedValue(indent, index string, arg DecodedArg) {
+	label := indent + index + " " + arg.Type
+	if arg.Name != "" {
+		label += " " + arg.Name
+	}
+
+	switch value := arg.Value.(type) {
+	case []DecodedArg:
+		fmt.Fprintln(output.Text, label+":")
+		for i, field := range value {
+			printDecodedValue(indent+"  ", fmt.Sprintf("[%d]", i), field)
+		}
+	case []any:
+		fmt.Fprintf(output.Text, "%s: %d item(s)\n", label, len(value))
+		elemType := arg.Type[:strings.LastIndex(arg.Type, "[")]
+		for i, item := range value {
+			printDecodedValue(indent+"  ", fmt.Sprintf("[%d]", i), DecodedArg{Type: elemType, Value: item})
+		}
+	default:
+		fmt.Fprintf(output.Text, "%s: %v\n", label, value)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// DecodeTxCmd represents the utils/decode/tx command
+var DecodeTxCmd = &cobra.Command{
+	Use:   "tx [raw]",
+	Short: "Decode a signed raw transaction, recover its sender and hash",
+	Example: `
+utils decode tx 0x02f8..:Decode a raw transaction
+trade sign | utils decode tx:Audit the output of trade sign
+utils decode tx -f signed.json --abi router.json:Decode an envelope file and its calldata`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/decode/tx called")
+		input, err := ReadRawInput(args, decodeTxFile)
+		if err != nil {
+			return err
+		}
+		tx, err := DecodeRawTx(input)
+		if err != nil {
+			return err
+		}
+
+		decoded, err := DescribeTx(tx)
+		if err != nil {
+			return err
+		}
+		if len(tx.Data()) >= 4 && tx.To() != nil {
+			decoded.Calls, _ = DecodeCalldata(tx.Data(), decodeABIs)
+		}
+
+		if output.Structured() {
+			return output.Print(decoded)
+		}
+		printDecodedTx(decoded)
+		return nil
+	},
+}
+
+// DecodedTx is the structured output of decode tx, amounts are in wei
+type DecodedTx struct {
+	Type                 uint8                  `json:"type"`
+	TypeName             string                 `json:"typeName"`
+	Hash                 string                 `json:"hash"`
+	ChainID              string                 `json:"chainId"`
+	From                 string                 `json:"from"`
+	To                   string                 `json:"to,omitempty"`
+	Nonce                uint64                 `json:"nonce"`
+	Value                string                 `json:"value"`
+	Gas                  uint64                 `json:"gas"`
+	GasPrice             string                 `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string                 `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string                 `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string                 `json:"maxFeePerBlobGas,omitempty"`
+	BlobHashes           []string               `json:"blobHashes,omitempty"`
+	AccessList           types.AccessList       `json:"accessList,omitempty"`
+	Authorizations       []DecodedAuthorization `json:"authorizations,omitempty"`
+	Data                 string                 `json:"data"`
+	Calls                []DecodedCall          `json:"calls,omitempty"`
+}
+
+// DecodedAuthorization is an EIP-7702 authorization with its recovered signer
+type DecodedAuthorization struct {
+	ChainID   string `json:"chainId"`
+	Address   string `json:"address"`
+	Nonce     uint64 `json:"nonce"`
+	Authority string `json:"authority"`
+}
+
+// Names of the transaction envelopes
+var txTypeNames = map[uint8]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "EIP-2930 access list",
+	types.DynamicFeeTxType: "EIP-1559 dynamic fee",
+	types.BlobTxType:       "EIP-4844 blob",
+	types.SetCodeTxType:    "EIP-7702 set code",
+}
+
+var decodeTxFile string
+
+func init() {
+	// Add command
+	DecodeCmd.AddCommand(DecodeTxCmd)
+
+	// Add flags
+	DecodeTxCmd.Flags().StringVarP(&decodeTxFile, "file", "f", "", "file holding the raw transaction or the envelope, - for stdin (default is stdin)")
+	DecodeTxCmd.Flags().StringArrayVar(&decodeABIs, "abi", nil, "ABI JSON file or compiled artifact, can be repeated")
+}
+
+// Read a raw transaction from the argument, a file or stdin
+func ReadRawInput(args []string, file string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	var content []byte
+	var err error
+	if file != "" && file != "-" {
+		content, err = os.ReadFile(file)
+	} else {
+		content, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Decode a raw transaction given as hex or as a JSON envelope with a raw field
+func DecodeRawTx(input string) (*types.Transaction, error) {
+	input = strings.TrimSpace(input)
+
+	// Take the envelope, or the last hex line of output piped from trade sign
+	if start, end := strings.Index(input, "{"), strings.LastIndex(input, "}"); start >= 0 && end > start {
+		var envelope struct {
+			Raw string `json:"raw"`
+		}
+		if err := json.Unmarshal([]byte(input[start:end+1]), &envelope); err != nil {
+			return nil, err
+		}
+		input = envelope.Raw
+	} else {
+		lines := strings.Split(input, "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := strings.TrimSpace(lines[i]); strings.HasPrefix(line, "0x") {
+				input = line
+				break
+			}
+		}
+	}
+
+	raw, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, errors.New("please enter a valid raw transaction")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Recover the sender of a signed transaction, unprotected legacy ones included
+func TxSender(tx *types.Transaction) (string, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return "", err
+	}
+	return from.Hex(), nil
+}
+
+// Describe every field of a signed transaction
+func DescribeTx(tx *types.Transaction) (DecodedTx, error) {
+	from, err := TxSender(tx)
+	if err != nil {
+		return DecodedTx{}, err
+	}
+
+	decoded := DecodedTx{
+		Type:       tx.Type(),
+		TypeName:   txTypeNames[tx.Type()],
+		Hash:       tx.Hash().Hex(),
+		ChainID:    tx.ChainId().String(),
+		From:       from,
+		Nonce:      tx.Nonce(),
+		Value:      tx.Value().String(),
+		Gas:        tx.Gas(),
+		AccessList: tx.AccessList(),
+		Data:       hexutil.Encode(tx.Data()),
+	}
+	if tx.To() != nil {
+		decoded.To = tx.To().Hex()
+	}
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		decoded.GasPrice = tx.GasPrice().String()
+	} else {
+		decoded.MaxFeePerGas = tx.GasFeeCap().String()
+		decoded.MaxPriorityFeePerGas = tx.GasTipCap().String()
+	}
+	if tx.Type() == types.BlobTxType {
+		decoded.MaxFeePerBlobGas = tx.BlobGasFeeCap().String()
+		for _, hash := range tx.BlobHashes() {
+			decoded.BlobHashes = append(decoded.BlobHashes, hash.Hex())
+		}
+	}
+	for _, auth := range tx.SetCodeAuthorizations() {
+		authority := "invalid signature"
+		if address, err := auth.Authority(); err == nil {
+			authority = address.Hex()
+		}
+		decoded.Authorizations = append(decoded.Authorizations, DecodedAuthorization{
+			ChainID:   auth.ChainID.String(),
+			Address:   auth.Address.Hex(),
+			Nonce:     auth.Nonce,
+			Authority: authority,
+		})
+	}
+	return decoded, nil
+}
+
+// Print the transaction with wei and gwei amounts, then its decoded calldata
+func printDecodedTx(decoded DecodedTx) {
+	gwei := func(wei string) string {
+		return EthNumberConverter(wei, "wei")["gwei"] + " gwei"
+	}
+	ether := func(wei string) string {
+		return EthNumberConverter(wei, "wei")["ether"] + " " + config.NativeSymbol()
+	}
+
+	fromColor, _ := GenAddressColor(decoded.From)
+	fmt.Fprintln(output.Text, "╔══════════════[ 📦 Raw transaction ]══════════════╗")
+	fmt.Fprintf(output.Text, "  %-9s: %d (%s)\n", "type", decoded.Type, decoded.TypeName)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "hash", decoded.Hash)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "chainId", decoded.ChainID)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "from", fromColor)
+	if decoded.To != "" {
+		toColor, _ := GenAddressColor(decoded.To)
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "to", toColor)
+	} else {
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "to", "contract creation")
+	}
+	fmt.Fprintf(output.Text, "  %-9s: %d\n", "nonce", decoded.Nonce)
+	fmt.Fprintf(output.Text, "  %-9s: %s (%s wei)\n", "value", ether(decoded.Value), decoded.Value)
+	fmt.Fprintf(output.Text, "  %-9s: %d\n", "gas", decoded.Gas)
+	if decoded.GasPrice != "" {
+		fmt.Fprintf(output.Text, "  %-9s: %s (%s wei)\n", "gasPrice", gwei(decoded.GasPrice), decoded.GasPrice)
+	} else {
+		fmt.Fprintf(output.Text, "  %-9s: %s (%s wei)\n", "maxFee", gwei(decoded.MaxFeePerGas), decoded.MaxFeePerGas)
+		fmt.Fprintf(output.Text, "  %-9s: %s (%s wei)\n", "tip", gwei(decoded.MaxPriorityFeePerGas), decoded.MaxPriorityFeePerGas)
+	}
+
+	// Worst case cost of the transaction
+	maxFee, _ := new(big.Int).SetString(decoded.GasPrice+decoded.MaxFeePerGas, 10)
+	cost := new(big.Int).Mul(maxFee, new(big.Int).SetUint64(decoded.Gas))
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "maxCost", ether(cost.String()))
+
+	if decoded.MaxFeePerBlobGas != "" {
+		fmt.Fprintf(output.Text, "  %-9s: %s (%s wei)\n", "blobFee", gwei(decoded.MaxFeePerBlobGas), decoded.MaxFeePerBlobGas)
+		for i, hash := range decoded.BlobHashes {
+			fmt.Fprintf(output.Text, "  %-9s: %s\n", fmt.Sprintf("blob[%d]", i), hash)
+		}
+	}
+	for _, tuple := range decoded.AccessList {
+		fmt.Fprintf(output.Text, "  %-9s: %s, %d storage key(s)\n", "access", tuple.Address.Hex(), len(tuple.StorageKeys))
+	}
+	for i, auth := range decoded.Authorizations {
+		fmt.Fprintf(output.Text, "  %-9s: %s delegates to %s (chain %s, nonce %d)\n", fmt.Sprintf("auth[%d]", i), auth.Authority, auth.Address, auth.ChainID, auth.Nonce)
+	}
+	if decoded.Data != "0x" {
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "data", decoded.Data)
+	}
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════════╝")
+
+	if len(decoded.Calls) > 0 {
+		PrintDecodedCalls(decoded.Calls)
+	} else if len(decoded.Data) >= 10 && decoded.To != "" {
+		fmt.Fprintln(output.Text, "<-- 🔍 Unknown selector", decoded.Data[:10], ", add the ABI with --abi -->")
+	}
+}
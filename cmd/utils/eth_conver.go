@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	output "txtoolbox/cmd/output"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/spf13/cobra"
@@ -51,7 +52,7 @@ Enter the quantity and unit to be converted, such as one of the following units:
 -n number -u tether
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("utils/ethConver called")
+		fmt.Fprintln(output.Text, "utils/ethConver called")
 
 		return checkInput()
 	},
@@ -60,6 +61,13 @@ Enter the quantity and unit to be converted, such as one of the following units:
 var number string
 var unit string
 
+// Conversion is the structured output of ethConver, values are keyed by unit
+type Conversion struct {
+	Number string            `json:"number"`
+	Unit   string            `json:"unit"`
+	Values map[string]string `json:"values"`
+}
+
 func init() {
 	// Add flags
 	EthConverCmd.Flags().StringVarP(&number, "number", "n", "", "number")
@@ -91,9 +99,13 @@ func checkInput() error {
 	// Convert
 	converResults := EthNumberConverter(number, unit)
 
+	if output.Structured() {
+		return output.Print(Conversion{Number: number, Unit: unit, Values: converResults})
+	}
+
 	// Print the results in order
 	for _, v := range UintsList {
-		fmt.Printf("%-7s: %s\n", v, converResults[v])
+		fmt.Fprintf(output.Text, "%-7s: %s\n", v, converResults[v])
 	}
 	return nil
 }
@@ -132,3 +144,25 @@ func EthNumberConverter(number, unit string) map[string]string {
 	}
 	return converResults
 }
+
+// Convert an amount in the given unit to an integer number of wei
+func ToWei(number, unit string) (*big.Int, error) {
+	if unit == "" {
+		unit = "wei"
+	}
+	if _, ok := UnitMultipliers[unit]; !ok {
+		return nil, errors.New("Check the units entered:<" + unit + ">")
+	}
+	if _, ok := new(big.Rat).SetString(number); !ok {
+		return nil, errors.New("Check the number entered:<" + number + ">")
+	}
+
+	wei, ok := new(big.Int).SetString(EthNumberConverter(number, unit)["wei"], 10)
+	if !ok {
+		return nil, errors.New("the number has more decimals than wei:<" + number + " " + unit + ">")
+	}
+	if wei.Sign() < 0 {
+		return nil, errors.New("the number cannot be negative:<" + number + ">")
+	}
+	return wei, nil
+}
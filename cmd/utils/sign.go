@@ -0,0 +1,379 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/spf13/cobra"
+)
+
+// SignCmd represents the utils/sign command
+var SignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign messages and EIP-712 typed data with the configured key",
+	Example: `
+utils sign message "Log in to example.com":Sign a personal_sign message
+utils sign typed-data -f permit.json:Sign EIP-712 typed data`,
+}
+
+// SignMessageCmd represents the utils/sign/message command
+var SignMessageCmd = &cobra.Command{
+	Use:   "message [message]",
+	Short: "Sign a message with EIP-191 personal_sign",
+	Example: `
+utils sign message "Log in to example.com":Sign a text message
+utils sign message --hex 0x1234:Sign raw bytes
+utils sign message -f message.txt:Sign the content of a file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/sign/message called")
+		input, err := ReadRawInput(args, signFile)
+		if err != nil {
+			return err
+		}
+		message := []byte(input)
+		if signHex {
+			if message, err = hexutil.Decode(strings.TrimSpace(input)); err != nil {
+				return errors.New("Check the message entered:<" + input + ">, it must be 0x-prefixed hex with --hex")
+			}
+		}
+
+		privateKey, err := signer.LoadKey()
+		if err != nil {
+			return err
+		}
+		hash := accounts.TextHash(message)
+		printMessage(message, hash, crypto.PubkeyToAddress(privateKey.PublicKey))
+		return confirmAndSign("personal_sign", hash, privateKey, nil)
+	},
+}
+
+// SignTypedDataCmd represents the utils/sign/typed-data command
+var SignTypedDataCmd = &cobra.Command{
+	Use:   "typed-data [json]",
+	Short: "Sign EIP-712 typed data",
+	Example: `
+utils sign typed-data -f permit.json:Sign typed data from a file
+cat order.json | utils sign typed-data -y --output json:Sign typed data from stdin`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/sign/typed-data called")
+		input, err := ReadRawInput(args, signFile)
+		if err != nil {
+			return err
+		}
+		typedData, err := ParseTypedData([]byte(input))
+		if err != nil {
+			return err
+		}
+		hash, domainSeparator, err := HashTypedData(typedData)
+		if err != nil {
+			return err
+		}
+
+		privateKey, err := signer.LoadKey()
+		if err != nil {
+			return err
+		}
+		PrintTypedData(typedData, crypto.PubkeyToAddress(privateKey.PublicKey))
+		fmt.Fprintf(output.Text, "<-- #️⃣  EIP-712 hash: %s -->\n", hexutil.Encode(hash))
+		return confirmAndSign("eip712", hash, privateKey, func(signature *Signature) {
+			signature.DomainSeparator = hexutil.Encode(domainSeparator)
+			signature.PrimaryType = typedData.PrimaryType
+		})
+	},
+}
+
+// Signature is the structured output of sign, v is 27 or 28
+type Signature struct {
+	Kind            string `json:"kind"`
+	Signer          string `json:"signer"`
+	PrimaryType     string `json:"primaryType,omitempty"`
+	DomainSeparator string `json:"domainSeparator,omitempty"`
+	Hash            string `json:"hash"`
+	Signature       string `json:"signature"`
+	R               string `json:"r"`
+	S               string `json:"s"`
+	V               uint8  `json:"v"`
+}
+
+var signFile string
+var signHex bool
+
+func init() {
+	// Add command
+	SignCmd.AddCommand(SignMessageCmd)
+	SignCmd.AddCommand(SignTypedDataCmd)
+
+	// Add flags
+	SignMessageCmd.Flags().StringVarP(&signFile, "file", "f", "", "file holding the message, - for stdin")
+	SignMessageCmd.Flags().BoolVar(&signHex, "hex", false, "the message is 0x-prefixed hex and is signed as raw bytes")
+	SignTypedDataCmd.Flags().StringVarP(&signFile, "file", "f", "", "file holding the typed data JSON, - for stdin")
+}
+
+// Ask before signing, then print the signature
+func confirmAndSign(kind string, hash []byte, privateKey *ecdsa.PrivateKey, fill func(*Signature)) error {
+	sign, err := prompt.Confirm("Sign it?")
+	if err != nil {
+		return err
+	}
+	if !sign {
+		os.Exit(0)
+	}
+
+	signature, err := SignHash(hash, privateKey)
+	if err != nil {
+		return err
+	}
+	signature.Kind = kind
+	if fill != nil {
+		fill(&signature)
+	}
+
+	if output.Structured() {
+		return output.Print(signature)
+	}
+	fmt.Fprintln(output.Text, "╔═══════════════[ ✍️  Signature ]═══════════════╗")
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "signer", signature.Signer)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "hash", signature.Hash)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "r", signature.R)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "s", signature.S)
+	fmt.Fprintf(output.Text, "  %-9s: %d\n", "v", signature.V)
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+	fmt.Fprintln(output.Text, signature.Signature)
+	return nil
+}
+
+// Sign a 32 byte hash, v is shifted to 27 or 28 as wallets return it
+func SignHash(hash []byte, privateKey *ecdsa.PrivateKey) (Signature, error) {
+	sig, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig[64] += 27
+	return Signature{
+		Signer:    crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+		Hash:      hexutil.Encode(hash),
+		Signature: hexutil.Encode(sig),
+		R:         hexutil.Encode(sig[:32]),
+		S:         hexutil.Encode(sig[32:64]),
+		V:         sig[64],
+	}, nil
+}
+
+// Print a personal_sign message as text when it is readable, and as hex otherwise
+func printMessage(message, hash []byte, from common.Address) {
+	fromColor, _ := GenAddressColor(from.Hex())
+	fmt.Fprintln(output.Text, "╔════════════[ ✉️  Message to sign ]════════════╗")
+	fmt.Fprintf(output.Text, "  %-7s: %s\n", "signer", fromColor)
+	fmt.Fprintf(output.Text, "  %-7s: %d bytes\n", "length", len(message))
+	if utf8.Valid(message) && !bytes.ContainsFunc(message, func(r rune) bool { return r < 0x20 && r != '\n' && r != '\t' }) {
+		for _, line := range strings.Split(string(message), "\n") {
+			fmt.Fprintf(output.Text, "  %-7s| %s\n", "", line)
+		}
+	} else {
+		fmt.Fprintf(output.Text, "  %-7s: %s\n", "hex", hexutil.Encode(message))
+	}
+	fmt.Fprintf(output.Text, "  %-7s: %s\n", "hash", hexutil.Encode(hash))
+	fmt.Fprintln(output.Text, "╚══════════════════════════════════════════════╝")
+}
+
+// Parse EIP-712 typed data, keeping large integers exact
+func ParseTypedData(content []byte) (apitypes.TypedData, error) {
+	var typedData apitypes.TypedData
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&typedData); err != nil {
+		return typedData, errors.New("please enter valid EIP-712 typed data: " + err.Error())
+	}
+	if typedData.PrimaryType == "" || typedData.Types[typedData.PrimaryType] == nil {
+		return typedData, errors.New("the typed data has no types for its primaryType:<" + typedData.PrimaryType + ">")
+	}
+	typedData.Message = numbersToStrings(map[string]any(typedData.Message)).(map[string]any)
+
+	// Like wallets, derive the domain type from the domain fields when it is left out
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		typedData.Types["EIP712Domain"] = DomainType(typedData.Domain)
+	}
+	return typedData, nil
+}
+
+// Integers are passed as strings, the hashing code reads JSON numbers as float64
+func numbersToStrings(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]any:
+		for key, item := range v {
+			v[key] = numbersToStrings(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = numbersToStrings(item)
+		}
+	}
+	return value
+}
+
+// The EIP712Domain type of the fields set in the domain, in the order of the standard
+func DomainType(domain apitypes.TypedDataDomain) []apitypes.Type {
+	var fields []apitypes.Type
+	if domain.Name != "" {
+		fields = append(fields, apitypes.Type{Name: "name", Type: "string"})
+	}
+	if domain.Version != "" {
+		fields = append(fields, apitypes.Type{Name: "version", Type: "string"})
+	}
+	if domain.ChainId != nil {
+		fields = append(fields, apitypes.Type{Name: "chainId", Type: "uint256"})
+	}
+	if domain.VerifyingContract != "" {
+		fields = append(fields, apitypes.Type{Name: "verifyingContract", Type: "address"})
+	}
+	if domain.Salt != "" {
+		fields = append(fields, apitypes.Type{Name: "salt", Type: "bytes32"})
+	}
+	return fields
+}
+
+// The EIP-712 hash to sign and the domain separator
+func HashTypedData(typedData apitypes.TypedData) ([]byte, []byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hash, domainSeparator, nil
+}
+
+// Print the domain and the message of typed data field by field
+func PrintTypedData(typedData apitypes.TypedData, from common.Address) {
+	fromColor, _ := GenAddressColor(from.Hex())
+	fmt.Fprintln(output.Text, "╔════════════[ ✍️  Typed data to sign ]════════════╗")
+	fmt.Fprintf(output.Text, "  %s: %s\n", "signer", fromColor)
+	fmt.Fprintln(output.Text, "  domain")
+	printTypedStruct(typedData, "EIP712Domain", typedData.Domain.Map(), "    ")
+	fmt.Fprintln(output.Text, " ", typedData.PrimaryType)
+	printTypedStruct(typedData, typedData.PrimaryType, typedData.Message, "    ")
+	fmt.Fprintln(output.Text, "╚═════════════════════════════════════════════════╝")
+
+	// Signatures for another chain can be replayed there
+	if typedData.Domain.ChainId != nil {
+		if chainID := configuredChainID(); chainID != nil && chainID.Cmp((*big.Int)(typedData.Domain.ChainId)) != 0 {
+			fmt.Fprintln(output.Text, "<-- ⚠️  The domain is for chain", (*big.Int)(typedData.Domain.ChainId), "but the configured chain is", chainID, "-->")
+		}
+	}
+}
+
+// Print the fields of one struct in the order of its type
+func printTypedStruct(typedData apitypes.TypedData, typeName string, data map[string]any, indent string) {
+	fields := typedData.Types[typeName]
+	width := 0
+	for _, field := range fields {
+		width = max(width, len(field.Name))
+	}
+	for _, field := range fields {
+		printTypedValue(typedData, field.Name, field.Type, data[field.Name], indent, width)
+	}
+}
+
+// Print one field, recursing into structs and arrays
+func printTypedValue(typedData apitypes.TypedData, name, typeName string, value any, indent string, width int) {
+	// Arrays list their elements under the field name
+	if i := strings.LastIndex(typeName, "["); i > 0 && strings.HasSuffix(typeName, "]") {
+		items, _ := value.([]any)
+		fmt.Fprintf(output.Text, "%s%-*s: %s, %d items\n", indent, width, name, typeName, len(items))
+		for j, item := range items {
+			printTypedValue(typedData, fmt.Sprintf("[%d]", j), typeName[:i], item, indent+"  ", 0)
+		}
+		return
+	}
+
+	if _, ok := typedData.Types[typeName]; ok {
+		fmt.Fprintf(output.Text, "%s%-*s: %s\n", indent, width, name, typeName)
+		nested, _ := value.(map[string]any)
+		printTypedStruct(typedData, typeName, nested, indent+"  ")
+		return
+	}
+
+	fmt.Fprintf(output.Text, "%s%-*s: %s\n", indent, width, name, formatTypedValue(name, typeName, value))
+}
+
+// Render addresses with their colors, and unlimited amounts and timestamps readably
+func formatTypedValue(name, typeName string, value any) string {
+	text := fmt.Sprint(value)
+	if number, ok := value.(*math.HexOrDecimal256); ok {
+		text = (*big.Int)(number).String()
+	}
+	switch {
+	case typeName == "address":
+		if common.IsHexAddress(text) {
+			addressColor, _ := GenAddressColor(common.HexToAddress(text).Hex())
+			return addressColor
+		}
+	case strings.HasPrefix(typeName, "uint"):
+		var number math.HexOrDecimal256
+		if number.UnmarshalText([]byte(text)) != nil {
+			return text
+		}
+		amount := (*big.Int)(&number)
+		if amount.Cmp(math.MaxBig256) == 0 {
+			return amount.String() + " (unlimited)"
+		}
+		if isTimeField(name) && amount.IsInt64() && amount.Int64() > 0 {
+			return amount.String() + " (" + time.Unix(amount.Int64(), 0).UTC().Format(time.RFC3339) + ")"
+		}
+		return amount.String()
+	}
+	return text
+}
+
+// Fields holding a unix timestamp, such as deadline, expiry or validBefore
+func isTimeField(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range []string{"deadline", "expir", "valid", "timestamp"} {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
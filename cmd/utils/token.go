@@ -0,0 +1,190 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ERC-20 functions used by the token commands
+var (
+	ERC20Decimals  = mustMethod("decimals()(uint8)")
+	ERC20Symbol    = mustMethod("symbol()(string)")
+	ERC20Name      = mustMethod("name()(string)")
+	ERC20BalanceOf = mustMethod("balanceOf(address)(uint256)")
+	ERC20Transfer  = mustMethod("transfer(address,uint256)(bool)")
+)
+
+// ERC-2612 functions used by trade permit
+var (
+	ERC2612Nonces          = mustMethod("nonces(address)(uint256)")
+	ERC2612DomainSeparator = mustMethod("DOMAIN_SEPARATOR()(bytes32)")
+	ERC2612Permit          = mustMethod("permit(address owner,address spender,uint256 value,uint256 deadline,uint8 v,bytes32 r,bytes32 s)")
+	EIP712Version          = mustMethod("version()(string)")
+)
+
+// Approval functions used by trade approve/revoke and utils allowances,
+// approve(address,uint256) is shared by ERC-20 amounts and ERC-721 token IDs
+var (
+	ERC20Allowance         = mustMethod("allowance(address,address)(uint256)")
+	ERC20Approve           = mustMethod("approve(address,uint256)")
+	ERC20IncreaseAllowance = mustMethod("increaseAllowance(address,uint256)")
+	ERC721GetApproved      = mustMethod("getApproved(uint256)(address)")
+	ERC721OwnerOf          = mustMethod("ownerOf(uint256)(address)")
+	SetApprovalForAll      = mustMethod("setApprovalForAll(address,bool)")
+	IsApprovedForAll       = mustMethod("isApprovedForAll(address,address)(bool)")
+	SupportsInterface      = mustMethod("supportsInterface(bytes4)(bool)")
+)
+
+// ERC-165 interface ID of ERC-1155
+var ERC1155InterfaceID = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+
+// Old tokens such as MKR return symbol() as bytes32
+var erc20SymbolBytes32 = mustMethod("symbol()(bytes32)")
+
+// Token metadata and the balance of one holder
+type TokenInfo struct {
+	Address  common.Address
+	Symbol   string
+	Decimals uint8
+	Balance  *big.Int
+}
+
+func mustMethod(signature string) abi.Method {
+	method, err := ParseMethodSignature(signature)
+	if err != nil {
+		panic(err)
+	}
+	return method
+}
+
+// Encode a call to method with already typed arguments
+func PackCall(method abi.Method, args ...any) ([]byte, error) {
+	packed, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, method.ID...), packed...), nil
+}
+
+// Call a view function on the latest block and decode its outputs
+func CallMethod(client *ethclient.Client, to common.Address, method abi.Method, args ...any) ([]any, error) {
+	data, err := PackCall(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%s returned nothing, is %s a contract?", method.Sig, to.Hex())
+	}
+	return method.Outputs.Unpack(result)
+}
+
+// Read decimals, symbol and the balance of owner from an ERC-20 contract
+func ReadToken(client *ethclient.Client, token, owner common.Address) (*TokenInfo, error) {
+	info := &TokenInfo{Address: token}
+
+	decimals, err := CallMethod(client, token, ERC20Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("decimals(): %v", err)
+	}
+	info.Decimals = decimals[0].(uint8)
+
+	info.Symbol = ReadSymbol(client, token)
+
+	balance, err := CallMethod(client, token, ERC20BalanceOf, owner)
+	if err != nil {
+		return nil, fmt.Errorf("balanceOf(): %v", err)
+	}
+	info.Balance = balance[0].(*big.Int)
+	return info, nil
+}
+
+// Read symbol() as a string or bytes32, falling back to ??? when it is missing
+func ReadSymbol(client *ethclient.Client, token common.Address) string {
+	if symbol, err := CallMethod(client, token, ERC20Symbol); err == nil {
+		return symbol[0].(string)
+	}
+	if symbol, err := CallMethod(client, token, erc20SymbolBytes32); err == nil {
+		raw := symbol[0].([32]byte)
+		return strings.TrimRight(string(raw[:]), "\x00")
+	}
+	return "???"
+}
+
+// Convert a human amount into the smallest token unit
+func ParseUnits(amount string, decimals uint8) (*big.Int, error) {
+	amountRat, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, errors.New("Check the number entered:<" + amount + ">")
+	}
+	if amountRat.Sign() < 0 {
+		return nil, errors.New("the number cannot be negative:<" + amount + ">")
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := new(big.Rat).Mul(amountRat, new(big.Rat).SetInt(scale))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("the number has more than %d decimals:<%s>", decimals, amount)
+	}
+	return new(big.Int).Set(scaled.Num()), nil
+}
+
+// Format an amount in the smallest token unit as a human amount
+func FormatUnits(value *big.Int, decimals uint8) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	result := new(big.Rat).SetFrac(value, scale).FloatString(int(decimals))
+	if strings.Contains(result, ".") {
+		result = strings.TrimRight(result, "0")
+		result = strings.TrimRight(result, ".")
+	}
+	return result
+}
+
+// Format an allowance in whole tokens, the maximum uint256 is shown as unlimited
+func FormatAllowance(value *big.Int, decimals uint8, symbol string) string {
+	if value.Cmp(math.MaxBig256) == 0 {
+		return "unlimited " + symbol
+	}
+	return FormatUnits(value, decimals) + " " + symbol
+}
+
+// The standard of a token with operator approvals, ERC-1155 is told apart through ERC-165
+func OperatorStandard(client *ethclient.Client, token common.Address) string {
+	if supported, err := CallMethod(client, token, SupportsInterface, ERC1155InterfaceID); err == nil && supported[0].(bool) {
+		return "erc1155"
+	}
+	return "erc721"
+}
@@ -63,6 +63,13 @@ var UtilsCmd = &cobra.Command{
 	Example: `
 ethConver -n number -u unit:Convert input to eth units
 checkAddrsss -h:Different functions for addresses
+vanity -p prefix:Generate vanity addresses or CREATE2 salts
+wallet derive -n 5:List the addresses of the mnemonic
+decode calldata 0x..:Decode calldata
+address create2 -h:Compute the address of a contract before it is deployed
+sign message "text":Sign messages and EIP-712 typed data
+verify "text" -s 0x..:Recover and check the signer of a signature
+allowances --from-block 0:List the active token approvals of the signer
 `,
 }
 
@@ -70,4 +77,11 @@ func init() {
 	//Add command
 	UtilsCmd.AddCommand(EthConverCmd)
 	UtilsCmd.AddCommand(CheckAddressCmd)
+	UtilsCmd.AddCommand(VanityCmd)
+	UtilsCmd.AddCommand(WalletCmd)
+	UtilsCmd.AddCommand(DecodeCmd)
+	UtilsCmd.AddCommand(AddressCmd)
+	UtilsCmd.AddCommand(SignCmd)
+	UtilsCmd.AddCommand(VerifyCmd)
+	UtilsCmd.AddCommand(AllowancesCmd)
 }
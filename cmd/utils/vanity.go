@@ -0,0 +1,339 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	"unicode"
+
+	"github.com/common-nighthawk/go-figure"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// VanityCmd represents the utils/vanity command
+var VanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Generate vanity addresses or CREATE2 salts using all CPU cores",
+	Long:  figure.NewFigure("vanity", "", true).String(),
+	Example: `
+utils vanity -p dead:Address starting with dead
+utils vanity -p Dead -s BEEF --case-sensitive:Match the EIP-55 checksum case
+utils vanity -r "^(.)\1{5}":Address matching a regular expression
+utils vanity -p 0000 --save:Encrypt the private key into a keystore and use it as the signer
+utils vanity -p 0000 --deployer 0x.. --init-code-hash 0x..:Mine a CREATE2 salt`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/vanity called")
+		return runVanity()
+	},
+}
+
+var vanityPrefix string
+var vanitySuffix string
+var vanityRegex string
+var vanityCaseSensitive bool
+var vanityWorkers int
+var vanityDeployer string
+var vanityInitCodeHash string
+var vanitySave bool
+var vanityForce bool
+var vanityDir string
+
+func init() {
+	// Add flags
+	VanityCmd.Flags().StringVarP(&vanityPrefix, "prefix", "p", "", "hex prefix of the address, without 0x")
+	VanityCmd.Flags().StringVarP(&vanitySuffix, "suffix", "s", "", "hex suffix of the address")
+	VanityCmd.Flags().StringVarP(&vanityRegex, "regex", "r", "", "regular expression matched against the 40 hex characters")
+	VanityCmd.Flags().BoolVar(&vanityCaseSensitive, "case-sensitive", false, "match the EIP-55 checksum case")
+	VanityCmd.Flags().IntVarP(&vanityWorkers, "workers", "w", runtime.NumCPU(), "number of worker goroutines")
+	VanityCmd.Flags().StringVar(&vanityDeployer, "deployer", "", "CREATE2 deployer address, mines a salt instead of a key")
+	VanityCmd.Flags().StringVar(&vanityInitCodeHash, "init-code-hash", "", "CREATE2 init code hash")
+	VanityCmd.Flags().BoolVar(&vanitySave, "save", false, "encrypt the private key into a keystore file and use it as the signer")
+	VanityCmd.Flags().BoolVar(&vanityForce, "force", false, "replace the configured signer when saving")
+	VanityCmd.Flags().StringVarP(&vanityDir, "dir", "d", "", "keystore directory (default is: keystore next to the configuration file)")
+}
+
+var hexPatternRegex = regexp.MustCompile(`^[0-9a-fA-F]*$`)
+
+// A matching address with its private key or CREATE2 salt
+type vanityResult struct {
+	Address common.Address
+	Key     []byte
+	Salt    [32]byte
+}
+
+// Match addresses against the prefix, suffix and regular expression
+type vanityMatcher struct {
+	prefix        string
+	suffix        string
+	regex         *regexp.Regexp
+	caseSensitive bool
+}
+
+func (m *vanityMatcher) match(address common.Address) bool {
+	var hexAddress string
+	if m.caseSensitive {
+		hexAddress = address.Hex()[2:]
+	} else {
+		hexAddress = hexutil.Encode(address[:])[2:]
+	}
+
+	if !strings.HasPrefix(hexAddress, m.prefix) || !strings.HasSuffix(hexAddress, m.suffix) {
+		return false
+	}
+	return m.regex == nil || m.regex.MatchString(hexAddress)
+}
+
+// Check input and search for a match
+func runVanity() error {
+	matcher, err := newVanityMatcher()
+	if err != nil {
+		return err
+	}
+	if vanityWorkers <= 0 {
+		return errors.New("workers must be greater than 0")
+	}
+
+	// Choose between key generation and CREATE2 salt mining
+	var generate func() (vanityResult, error)
+	if vanityDeployer != "" || vanityInitCodeHash != "" {
+		if vanitySave {
+			return errors.New("--save only applies to private keys, not CREATE2 salts")
+		}
+		generate, err = create2Generator()
+		if err != nil {
+			return err
+		}
+	} else {
+		generate = keyGenerator
+	}
+	// Check before searching, a match can take hours
+	if vanitySave {
+		if err := checkSignerFree("keystore", vanityForce); err != nil {
+			return err
+		}
+	}
+
+	difficulty := vanityDifficulty(matcher)
+	fmt.Fprintf(output.Text, "<-- 🎯 Searching with %d workers, difficulty: %.0f -->\n", vanityWorkers, difficulty)
+
+	result, err := searchVanity(matcher, generate, difficulty)
+	if err != nil {
+		return err
+	}
+
+	color, _ := GenAddressColor(result.Address.Hex())
+	fmt.Fprintln(output.Text, "<-- 🎉 Address found:", color, "-->")
+	if result.Key == nil {
+		fmt.Fprintln(output.Text, "Salt -> ", hexutil.Encode(result.Salt[:]))
+		return nil
+	}
+
+	if vanitySave {
+		privateKey, err := crypto.ToECDSA(result.Key)
+		if err != nil {
+			return err
+		}
+		dir := vanityDir
+		if dir == "" {
+			dir = config.DefaultKeystoreDir()
+		}
+		path, err := config.ImportKey(privateKey, dir)
+		if err != nil {
+			return err
+		}
+		if err := config.SaveSigner(path, result.Address.Hex()); err != nil {
+			return err
+		}
+		fmt.Fprintln(output.Text, "<-- 🥷  Keystore saved as the signer:", path, "-->")
+		return nil
+	}
+	fmt.Fprintln(output.Text, "Private key -> ", hexutil.Encode(result.Key))
+	return nil
+}
+
+// Build the matcher from the flags
+func newVanityMatcher() (*vanityMatcher, error) {
+	prefix := strings.TrimPrefix(vanityPrefix, "0x")
+	suffix := vanitySuffix
+	for _, pattern := range []string{prefix, suffix} {
+		if len(pattern) > 40 {
+			return nil, errors.New("the pattern is longer than an address")
+		}
+		if !hexPatternRegex.MatchString(pattern) {
+			return nil, errors.New("Check the pattern entered:<" + pattern + ">, only hex characters are allowed")
+		}
+	}
+	if prefix == "" && suffix == "" && vanityRegex == "" {
+		return nil, errors.New("please enter a prefix, suffix or regex")
+	}
+
+	matcher := &vanityMatcher{caseSensitive: vanityCaseSensitive}
+	if vanityCaseSensitive {
+		matcher.prefix, matcher.suffix = prefix, suffix
+	} else {
+		matcher.prefix, matcher.suffix = strings.ToLower(prefix), strings.ToLower(suffix)
+	}
+	if vanityRegex != "" {
+		regex, err := regexp.Compile(vanityRegex)
+		if err != nil {
+			return nil, err
+		}
+		matcher.regex = regex
+	}
+	return matcher, nil
+}
+
+// Expected number of attempts, 0 when a regex makes it unknown
+func vanityDifficulty(m *vanityMatcher) float64 {
+	if m.regex != nil {
+		return 0
+	}
+	difficulty := 1.0
+	for _, c := range m.prefix + m.suffix {
+		difficulty *= 16
+		// Checksum case halves the chance for every letter
+		if m.caseSensitive && unicode.IsLetter(c) {
+			difficulty *= 2
+		}
+	}
+	return difficulty
+}
+
+// Generate a fresh secp256k1 key
+func keyGenerator() (vanityResult, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return vanityResult{}, err
+	}
+	return vanityResult{Address: crypto.PubkeyToAddress(key.PublicKey), Key: crypto.FromECDSA(key)}, nil
+}
+
+// Mine CREATE2 salts for the deployer and init code hash
+func create2Generator() (func() (vanityResult, error), error) {
+	deployer, err := ParseAddress(vanityDeployer)
+	if err != nil {
+		return nil, err
+	}
+
+	initCodeHash, err := hexutil.Decode(vanityInitCodeHash)
+	if err != nil || len(initCodeHash) != 32 {
+		return nil, errors.New("please enter a valid 32 byte --init-code-hash")
+	}
+
+	return func() (vanityResult, error) {
+		var salt [32]byte
+		if _, err := rand.Read(salt[:]); err != nil {
+			return vanityResult{}, err
+		}
+		return vanityResult{Address: crypto.CreateAddress2(deployer, salt, initCodeHash), Salt: salt}, nil
+	}, nil
+}
+
+// Run the workers until one of them finds a match, reporting progress every second
+func searchVanity(matcher *vanityMatcher, generate func() (vanityResult, error), difficulty float64) (vanityResult, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts atomic.Uint64
+	found := make(chan vanityResult, 1)
+	failed := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < vanityWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				result, err := generate()
+				if err != nil {
+					select {
+					case failed <- err:
+					default:
+					}
+					cancel()
+					return
+				}
+				attempts.Add(1)
+				if matcher.match(result.Address) {
+					select {
+					case found <- result:
+					default:
+					}
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case result := <-found:
+			wg.Wait()
+			printVanityProgress(attempts.Load(), time.Since(start), difficulty)
+			fmt.Fprintln(output.Text)
+			return result, nil
+		case err := <-failed:
+			wg.Wait()
+			fmt.Fprintln(output.Text)
+			return vanityResult{}, err
+		case <-ticker.C:
+			printVanityProgress(attempts.Load(), time.Since(start), difficulty)
+		}
+	}
+}
+
+// Print attempts, keys per second and the expected time
+func printVanityProgress(attempts uint64, elapsed time.Duration, difficulty float64) {
+	rate := float64(attempts) / elapsed.Seconds()
+	line := fmt.Sprintf("\r  attempts: %d  speed: %.0f/s  elapsed: %s", attempts, rate, elapsed.Round(time.Second))
+
+	// 50% of searches finish within difficulty*ln2 attempts
+	if difficulty > 0 && rate > 0 {
+		seconds := difficulty * math.Ln2 / rate
+		if seconds < float64(math.MaxInt64/int64(time.Second)) {
+			line += fmt.Sprintf("  50%% chance within: %s", (time.Duration(seconds) * time.Second).Round(time.Second))
+		} else {
+			line += fmt.Sprintf("  50%% chance within: %.1e years", seconds/(365*24*3600))
+		}
+	}
+	fmt.Fprint(output.Text, line)
+}
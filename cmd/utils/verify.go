@@ -0,0 +1,250 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// VerifyCmd represents the utils/verify command
+var VerifyCmd = &cobra.Command{
+	Use:   "verify [message]",
+	Short: "Recover the signer of a message or EIP-712 typed data and check it",
+	Example: `
+utils verify "Log in to example.com" -s 0x..:Recover the signer of a personal_sign message
+utils verify "Log in to example.com" -s 0x.. -a 0x..:Check the signer against the expected address
+utils verify --typed-data -f permit.json -s 0x.. -a 0x..:Check an EIP-712 signature
+utils verify --typed-data -f order.json -s 0x.. -a 0xSafe --erc1271:Ask a contract wallet with isValidSignature`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/verify called")
+		signature, err := hexutil.Decode(strings.TrimSpace(verifySignature))
+		if err != nil {
+			return errors.New("Check the signature entered:<" + verifySignature + ">")
+		}
+		var expected common.Address
+		if verifyAddress != "" {
+			if expected, err = ParseAddress(verifyAddress); err != nil {
+				return err
+			}
+		} else if verifyERC1271 {
+			return errors.New("please enter the contract wallet address with --address")
+		}
+
+		input, err := ReadRawInput(args, verifyFile)
+		if err != nil {
+			return err
+		}
+		result := Verification{Kind: "personal_sign"}
+		var hash []byte
+		var show func(common.Address)
+		if verifyTypedData {
+			typedData, err := ParseTypedData([]byte(input))
+			if err != nil {
+				return err
+			}
+			if hash, _, err = HashTypedData(typedData); err != nil {
+				return err
+			}
+			result.Kind = "eip712"
+			show = func(signer common.Address) { PrintTypedData(typedData, signer) }
+		} else {
+			message := []byte(input)
+			if verifyHex {
+				if message, err = hexutil.Decode(strings.TrimSpace(input)); err != nil {
+					return errors.New("Check the message entered:<" + input + ">, it must be 0x-prefixed hex with --hex")
+				}
+			}
+			hash = accounts.TextHash(message)
+			show = func(signer common.Address) { printMessage(message, hash, signer) }
+		}
+		result.Hash = hexutil.Encode(hash)
+
+		// Contract wallets may use signatures that are not a single ECDSA signature
+		recovered, recoverErr := RecoverSigner(hash, signature)
+		if recoverErr == nil {
+			result.Recovered = recovered.Hex()
+			result.HighS = isHighS(signature)
+		} else if !verifyERC1271 {
+			return recoverErr
+		}
+
+		if verifyAddress != "" {
+			result.Expected = expected.Hex()
+			if recoverErr == nil {
+				// Compare in lowercase so the checksum case does not show as a difference
+				_, _, result.Positions = colorDiff(strings.ToLower(result.Recovered), strings.ToLower(result.Expected))
+				match := len(result.Positions) == 0
+				result.Match = &match
+			}
+		}
+		if verifyERC1271 {
+			valid, err := isValidSignature(expected, hash, signature)
+			if err != nil {
+				return err
+			}
+			result.ERC1271 = &valid
+		}
+		result.Valid = recoverErr == nil && (result.Match == nil || *result.Match)
+		if result.ERC1271 != nil {
+			result.Valid = *result.ERC1271
+		}
+
+		if output.Structured() {
+			if err := output.Print(result); err != nil {
+				return err
+			}
+		} else {
+			shown := expected
+			if recoverErr == nil {
+				shown = recovered
+			}
+			show(shown)
+			printVerification(result)
+		}
+		if !result.Valid {
+			return errors.New("the signature is not valid for " + result.Expected)
+		}
+		return nil
+	},
+}
+
+// Verification is the structured output of verify
+type Verification struct {
+	Kind      string `json:"kind"`
+	Hash      string `json:"hash"`
+	Recovered string `json:"recovered,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Match     *bool  `json:"match,omitempty"`
+	Positions []int  `json:"positions,omitempty"`
+	ERC1271   *bool  `json:"erc1271,omitempty"`
+	HighS     bool   `json:"highS"`
+	Valid     bool   `json:"valid"`
+}
+
+// ERC-1271 magic value returned for valid signatures
+var erc1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+var erc1271IsValidSignature = mustMethod("isValidSignature(bytes32,bytes)(bytes4)")
+
+// Half of the secp256k1 order, signatures above it are malleable
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+var verifySignature string
+var verifyAddress string
+var verifyFile string
+var verifyHex bool
+var verifyTypedData bool
+var verifyERC1271 bool
+
+func init() {
+	// Add flags
+	VerifyCmd.Flags().StringVarP(&verifySignature, "signature", "s", "", "65 byte signature, r s v")
+	VerifyCmd.Flags().StringVarP(&verifyAddress, "address", "a", "", "expected signer, or the contract wallet with --erc1271")
+	VerifyCmd.Flags().StringVarP(&verifyFile, "file", "f", "", "file holding the message or typed data, - for stdin")
+	VerifyCmd.Flags().BoolVar(&verifyHex, "hex", false, "the message is 0x-prefixed hex and was signed as raw bytes")
+	VerifyCmd.Flags().BoolVar(&verifyTypedData, "typed-data", false, "the input is EIP-712 typed data JSON")
+	VerifyCmd.Flags().BoolVar(&verifyERC1271, "erc1271", false, "call isValidSignature on --address through the configured network")
+	VerifyCmd.MarkFlagRequired("signature")
+	VerifyCmd.MarkFlagsMutuallyExclusive("hex", "typed-data")
+}
+
+// Recover the signer of a hash from a 65 byte signature, v may be 0/1 or 27/28
+func RecoverSigner(hash, signature []byte) (common.Address, error) {
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("the signature is %d bytes, expected 65", len(signature))
+	}
+	sig := append([]byte{}, signature...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, fmt.Errorf("invalid signature v:<%d>", signature[64])
+	}
+	publicKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// Whether s is in the upper half of the curve order, which OpenZeppelin ECDSA rejects
+func isHighS(signature []byte) bool {
+	return new(big.Int).SetBytes(signature[32:64]).Cmp(secp256k1HalfN) > 0
+}
+
+// Ask a contract wallet whether the signature is valid for the hash
+func isValidSignature(wallet common.Address, hash, signature []byte) (bool, error) {
+	client, _, err := config.DialNetwork()
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	result, err := CallMethod(client, wallet, erc1271IsValidSignature, [32]byte(hash), signature)
+	if err != nil {
+		// Wallets revert on invalid signatures as often as they return another value
+		fmt.Fprintln(output.Text, "<-- ⚠️  isValidSignature failed:", err, "-->")
+		return false, nil
+	}
+	return result[0].([4]byte) == erc1271MagicValue, nil
+}
+
+// Print the recovered signer against the expected one
+func printVerification(result Verification) {
+	fmt.Fprintln(output.Text, "╔═══════════[ 🔏 Signature verification ]═══════════╗")
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "kind", result.Kind)
+	fmt.Fprintf(output.Text, "  %-9s: %s\n", "hash", result.Hash)
+	if result.Recovered != "" {
+		recoveredColor, _ := GenAddressColor(result.Recovered)
+		fmt.Fprintf(output.Text, "  %-9s: %s\n", "recovered", recoveredColor)
+	}
+	if result.ERC1271 != nil {
+		fmt.Fprintf(output.Text, "  %-9s: %v\n", "ERC-1271", *result.ERC1271)
+	}
+	fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════════════╝")
+	if result.HighS {
+		fmt.Fprintln(output.Text, "<-- ⚠️  The signature has a high s value, contracts using OpenZeppelin ECDSA reject it -->")
+	}
+
+	if result.Match != nil {
+		line1, line2, _ := colorDiff(strings.ToLower(result.Recovered), strings.ToLower(result.Expected))
+		fmt.Fprintln(output.Text, "Recovered address -> ", line1)
+		fmt.Fprintln(output.Text, "Expected address -> ", line2)
+		fmt.Fprintln(output.Text, "Match -> ", *result.Match)
+	}
+	if result.Valid {
+		fmt.Fprintln(output.Text, "<-- ✅ Valid signature -->")
+	} else {
+		fmt.Fprintln(output.Text, "<-- ❌ Invalid signature -->")
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright © 2024 DracoYan-111 <yanlong2944@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"slices"
+	config "txtoolbox/cmd/config"
+	output "txtoolbox/cmd/output"
+	prompt "txtoolbox/cmd/prompt"
+	signer "txtoolbox/cmd/signer"
+
+	"github.com/common-nighthawk/go-figure"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// WalletCmd represents the utils/wallet command
+var WalletCmd = &cobra.Command{
+	Use:   "wallet",
+	Short: "HD wallet tools",
+	Long:  figure.NewFigure("Wallet", "", true).String(),
+	Example: `
+utils wallet new:Generate a private key
+utils wallet new --mnemonic:Generate a mnemonic
+utils wallet derive -n 10:List the first addresses of the mnemonic`,
+}
+
+// WalletNewCmd represents the utils/wallet/new command
+var WalletNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Generate a private key or a mnemonic",
+	Example: `
+utils wallet new:Generate a private key and print it
+utils wallet new --mnemonic --words 24:Generate a 24 word mnemonic
+utils wallet new --keystore:Encrypt the new key into ./keystore
+utils wallet new --keystore --save:Use the new keystore as the signer of the active profile
+utils wallet new --mnemonic --save:Write the mnemonic into the configuration file`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/wallet/new called")
+
+		// Profiles only reference keystore files
+		if walletSave && !walletKeystore && config.ActiveProfile() != "" {
+			return errors.New("profiles only hold keystore files, add --keystore to save into profile " + config.ActiveProfile())
+		}
+		if walletSave {
+			saving := "privateKey"
+			if walletKeystore {
+				saving = "keystore"
+			} else if walletMnemonic {
+				saving = "mnemonic"
+			}
+			if err := checkSignerFree(saving, walletForce); err != nil {
+				return err
+			}
+		}
+		entropyBits, ok := mnemonicEntropy[walletWords]
+		if !ok {
+			return fmt.Errorf("Check the words entered:<%d>, use 12/15/18/21/24", walletWords)
+		}
+
+		// Both are generated from crypto/rand
+		wallet := NewWallet{}
+		var privateKey *ecdsa.PrivateKey
+		template := derivePath
+		if template == "" {
+			template = viper.GetString("derivationPath")
+		}
+		if template == "" {
+			template = signer.DefaultDerivationPath
+		}
+		if walletMnemonic {
+			entropy, err := bip39.NewEntropy(entropyBits)
+			if err != nil {
+				return err
+			}
+			if wallet.Mnemonic, err = bip39.NewMnemonic(entropy); err != nil {
+				return err
+			}
+			seed, err := signer.MnemonicSeed(wallet.Mnemonic, "")
+			if err != nil {
+				return err
+			}
+			path, err := signer.DerivationPath(template, 0)
+			if err != nil {
+				return err
+			}
+			if privateKey, err = signer.DeriveKey(seed, path); err != nil {
+				return err
+			}
+			wallet.Path = path.String()
+		} else {
+			var err error
+			if privateKey, err = crypto.GenerateKey(); err != nil {
+				return err
+			}
+		}
+		wallet.Address = crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+		// Encrypt the key
+		if walletKeystore {
+			dir := walletDir
+			if dir == "" {
+				dir = config.DefaultKeystoreDir()
+			}
+			path, err := config.ImportKey(privateKey, dir)
+			if err != nil {
+				return err
+			}
+			wallet.Keystore = path
+		}
+
+		// Save the keystore, or the plaintext secret, as the signer
+		if walletSave {
+			var err error
+			switch {
+			case wallet.Keystore != "":
+				err = config.SaveSigner(wallet.Keystore, wallet.Address)
+			case walletMnemonic:
+				// A passphrase, index or path left from an older mnemonic would derive another account than the printed one
+				settings := [][2]string{{"mnemonic", wallet.Mnemonic}, {"mnemonicPassphrase", ""}, {"accountIndex", "0"}, {"derivationPath", template}}
+				for _, setting := range settings {
+					if err = config.AddConfig(setting[0], setting[1]); err != nil {
+						break
+					}
+				}
+			default:
+				err = config.AddConfig("privateKey", hexutil.Encode(crypto.FromECDSA(privateKey)))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// A key that is not encrypted is printed so there is a copy besides the configuration file
+		if !walletMnemonic && wallet.Keystore == "" {
+			wallet.PrivateKey = hexutil.Encode(crypto.FromECDSA(privateKey))
+		}
+
+		if output.Structured() {
+			return output.Print(wallet)
+		}
+		color, _ := GenAddressColor(wallet.Address)
+		fmt.Fprintln(output.Text, "╔══════════════[ 🔑 New wallet ]══════════════╗")
+		fmt.Fprintf(output.Text, "  %-8s: %s\n", "address", color)
+		if wallet.Path != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "path", wallet.Path)
+		}
+		if wallet.Keystore != "" {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "keystore", wallet.Keystore)
+		}
+		if walletSave {
+			fmt.Fprintf(output.Text, "  %-8s: %s\n", "saved", viper.ConfigFileUsed())
+		}
+		fmt.Fprintln(output.Text, "╚═════════════════════════════════════════════╝")
+		if wallet.Mnemonic != "" {
+			fmt.Fprintln(output.Text, "<-- ⚠️  Write down the mnemonic and keep it offline, it is the only backup -->")
+			fmt.Fprintln(output.Text, " ", wallet.Mnemonic)
+		}
+		if wallet.PrivateKey != "" {
+			if walletSave {
+				fmt.Fprintln(output.Text, "<-- ⚠️  The private key is saved in plaintext, keep a copy offline -->")
+			} else {
+				fmt.Fprintln(output.Text, "<-- ⚠️  The private key is not stored anywhere, keep it safe -->")
+			}
+			fmt.Fprintln(output.Text, " ", wallet.PrivateKey)
+		}
+		return nil
+	},
+}
+
+// WalletDeriveCmd represents the utils/wallet/derive command
+var WalletDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "List the addresses derived from the mnemonic",
+	Example: `
+utils wallet derive:List the first 5 addresses of the mnemonic key
+utils wallet derive -n 20 --start 100:List addresses 100 to 119
+utils wallet derive --path "m/44'/60'/N'/0/0":Ledger Live style accounts`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(output.Text, "utils/wallet/derive called")
+
+		// The mnemonic key is used when set, otherwise the mnemonic is asked for
+		mnemonic := viper.GetString("mnemonic")
+		passphrase := viper.GetString("mnemonicPassphrase")
+		if mnemonic == "" {
+			var err error
+			if mnemonic, err = prompt.Password("Mnemonic"); err != nil {
+				return err
+			}
+			if passphrase, err = prompt.Password("BIP-39 passphrase (empty for none)"); err != nil {
+				return err
+			}
+		}
+		seed, err := signer.MnemonicSeed(mnemonic, passphrase)
+		if err != nil {
+			return err
+		}
+
+		template := derivePath
+		if template == "" {
+			template = viper.GetString("derivationPath")
+		}
+
+		var derived []DerivedAccount
+		for index := deriveStart; index < deriveStart+deriveCount; index++ {
+			path, err := signer.DerivationPath(template, index)
+			if err != nil {
+				return err
+			}
+			key, err := signer.DeriveKey(seed, path)
+			if err != nil {
+				return err
+			}
+			derived = append(derived, DerivedAccount{
+				Index:   index,
+				Path:    path.String(),
+				Address: crypto.PubkeyToAddress(key.PublicKey).Hex(),
+			})
+		}
+
+		if output.Structured() {
+			return output.Print(derived)
+		}
+		fmt.Fprintln(output.Text, "╔══════════[ 🌱 Derived accounts ]══════════╗")
+		for _, account := range derived {
+			color, _ := GenAddressColor(account.Address)
+			fmt.Fprintf(output.Text, "  [%d] %-20s %s\n", account.Index, account.Path, color)
+		}
+		fmt.Fprintln(output.Text, "╚═══════════════════════════════════════════╝")
+		return nil
+	},
+}
+
+// DerivedAccount is an address derived from the mnemonic
+type DerivedAccount struct {
+	Index   uint32 `json:"index"`
+	Path    string `json:"path"`
+	Address string `json:"address"`
+}
+
+// NewWallet is a generated key, secrets are only set when they must be written down
+type NewWallet struct {
+	Address    string `json:"address"`
+	Path       string `json:"path,omitempty"`
+	Mnemonic   string `json:"mnemonic,omitempty"`
+	PrivateKey string `json:"privateKey,omitempty"`
+	Keystore   string `json:"keystore,omitempty"`
+}
+
+// Refuse to replace the configured signer, whose secret may be stored nowhere else,
+// and to save a key that LoadKey would never use because a higher ranked signer is set
+func checkSignerFree(saving string, force bool) error {
+	configured := signer.ConfiguredSigner()
+	if configured == "" {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("a signer is already configured as %s, back it up and add --force to replace it", configured)
+	}
+	if slices.Index(signer.SignerKeys, configured) < slices.Index(signer.SignerKeys, saving) {
+		return fmt.Errorf("the configured %s takes precedence over %s, save the new key with --keystore", configured, saving)
+	}
+	return nil
+}
+
+// Entropy bits of each mnemonic length
+var mnemonicEntropy = map[int]int{12: 128, 15: 160, 18: 192, 21: 224, 24: 256}
+
+var walletMnemonic bool
+var walletWords int
+var walletKeystore bool
+var walletDir string
+var walletSave bool
+var walletForce bool
+
+var deriveCount uint32
+var deriveStart uint32
+var derivePath string
+
+func init() {
+	// Add command
+	WalletCmd.AddCommand(WalletNewCmd)
+	WalletCmd.AddCommand(WalletDeriveCmd)
+
+	// Add flags
+	WalletNewCmd.Flags().BoolVar(&walletMnemonic, "mnemonic", false, "generate a BIP-39 mnemonic instead of a private key")
+	WalletNewCmd.Flags().IntVar(&walletWords, "words", 12, "number of mnemonic words: 12/15/18/21/24")
+	WalletNewCmd.Flags().StringVar(&derivePath, "path", "", "derivation path of the first account (default is the derivationPath key, or "+signer.DefaultDerivationPath+")")
+	WalletNewCmd.Flags().BoolVar(&walletKeystore, "keystore", false, "encrypt the key into a keystore file")
+	WalletNewCmd.Flags().StringVarP(&walletDir, "dir", "d", "", "keystore directory (default is: keystore next to the configuration file)")
+	WalletNewCmd.Flags().BoolVar(&walletSave, "save", false, "use the new wallet as the signer of the active profile or configuration file")
+	WalletNewCmd.Flags().BoolVar(&walletForce, "force", false, "replace the configured signer when saving")
+
+	WalletDeriveCmd.Flags().Uint32VarP(&deriveCount, "number", "n", 5, "number of addresses")
+	WalletDeriveCmd.Flags().Uint32Var(&deriveStart, "start", 0, "first account index")
+	WalletDeriveCmd.Flags().StringVar(&derivePath, "path", "", "derivation path, N is the account index (default is the derivationPath key, or "+signer.DefaultDerivationPath+")")
+}